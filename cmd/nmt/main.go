@@ -0,0 +1,470 @@
+// Command nmt builds namespaced Merkle trees from files of leaves, and
+// generates and verifies their proofs.
+//
+// Usage:
+//
+//	nmt root   [flags] LEAVES
+//	nmt prove  [flags] (-namespace NID | -range START:END) LEAVES
+//	nmt verify [flags] -root ROOT -proof PROOF [-namespace NID] LEAVES
+//
+// LEAVES is a file of namespaced leaves, i.e., leaves prefixed with their
+// namespace ID, or - for the standard input. With -format hex (the default),
+// it holds one hex encoded leaf per line, blank lines being ignored. With
+// -format binary, every leaf is prefixed with its length as an unsigned varint.
+//
+// root prints the hex encoded root of the tree of the leaves. prove writes the
+// JSON encoded proof of the leaves of a namespace or of a range of leaves.
+// verify checks a proof against a root and the proven leaves, i.e., the leaves
+// of the namespace or of the range only, and exits with status 1 and the
+// reason of the failure if the proof is invalid. Namespace proofs are verified
+// with Proof.VerifyNamespace, range proofs by checking the inclusion of the
+// leaves in the range.
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/celestiaorg/nmt"
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+var hashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// errInvalidProof is returned by the verify command when the proof is invalid,
+// as opposed to a usage or I/O error.
+var errInvalidProof = errors.New("invalid proof")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with the given arguments and returns its exit status:
+// 0 on success, 1 if a proof is invalid or the command failed, and 2 on usage
+// errors.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	var cmd func([]string, io.Reader, io.Writer) error
+	switch args[0] {
+	case "root":
+		cmd = runRoot
+	case "prove":
+		cmd = runProve
+	case "verify":
+		cmd = runVerify
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "nmt: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err := cmd(args[1:], stdin, stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(stderr, "nmt %s: %v\n", args[0], err)
+		var uerr usageError
+		if errors.As(err, &uerr) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `usage:
+	nmt root   [flags] LEAVES
+	nmt prove  [flags] (-namespace NID | -range START:END) LEAVES
+	nmt verify [flags] -root ROOT -proof PROOF [-namespace NID] LEAVES
+
+Run "nmt COMMAND -h" for the flags of a command.
+`)
+}
+
+// usageError is an error in the command line arguments.
+type usageError struct{ error }
+
+func usageErrorf(format string, a ...any) error {
+	return usageError{fmt.Errorf(format, a...)}
+}
+
+// treeFlags are the flags describing the tree, shared by all the commands.
+type treeFlags struct {
+	nsSize    int
+	hashName  string
+	format    string
+	ignoreMax bool
+}
+
+func (f *treeFlags) register(fs *flag.FlagSet, withIgnoreMax bool) {
+	fs.IntVar(&f.nsSize, "ns-size", nmt.DefaultNamespaceIDLen, "size of the namespace IDs in bytes")
+	fs.StringVar(&f.hashName, "hash", "sha256", "hash function: "+strings.Join(hashNames(), ", "))
+	fs.StringVar(&f.format, "format", "hex", "format of the leaves file: hex or binary")
+	if withIgnoreMax {
+		fs.BoolVar(&f.ignoreMax, "ignore-max-namespace", true, "ignore the maximum namespace ID when computing the namespace range of the nodes")
+	}
+}
+
+func (f *treeFlags) hash() (hash.Hash, error) {
+	newHash, ok := hashes[f.hashName]
+	if !ok {
+		return nil, usageErrorf("unknown hash function %q", f.hashName)
+	}
+	return newHash(), nil
+}
+
+// tree builds the tree of the leaves in the file named by path.
+func (f *treeFlags) tree(path string, stdin io.Reader) (*nmt.NamespacedMerkleTree, error) {
+	h, err := f.hash()
+	if err != nil {
+		return nil, err
+	}
+	leaves, err := f.readLeaves(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	tree := nmt.New(h, nmt.NamespaceIDSize(f.nsSize), nmt.IgnoreMaxNamespace(f.ignoreMax), nmt.InitialCapacity(len(leaves)))
+	for i, leaf := range leaves {
+		if err := tree.Push(leaf); err != nil {
+			return nil, fmt.Errorf("leaf %d: %w", i, err)
+		}
+	}
+	return tree, nil
+}
+
+func (f *treeFlags) readLeaves(path string, stdin io.Reader) ([][]byte, error) {
+	if f.nsSize < 0 || f.nsSize > namespace.IDMaxSize {
+		return nil, usageErrorf("namespace size %d is not in [0, %d]", f.nsSize, namespace.IDMaxSize)
+	}
+	r := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	switch f.format {
+	case "hex":
+		return readHexLeaves(r)
+	case "binary":
+		return readBinaryLeaves(r)
+	default:
+		return nil, usageErrorf("unknown leaves format %q", f.format)
+	}
+}
+
+func runRoot(args []string, stdin io.Reader, stdout io.Writer) error {
+	var tf treeFlags
+	fs := newFlagSet("root", "[flags] LEAVES")
+	tf.register(fs, true)
+	path, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	tree, err := tf.tree(path, stdin)
+	if err != nil {
+		return err
+	}
+	root, err := tree.Root()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, hex.EncodeToString(root))
+	return err
+}
+
+func runProve(args []string, stdin io.Reader, stdout io.Writer) error {
+	var tf treeFlags
+	var nsHex, rangeArg, out string
+	fs := newFlagSet("prove", "[flags] (-namespace NID | -range START:END) LEAVES")
+	tf.register(fs, true)
+	fs.StringVar(&nsHex, "namespace", "", "hex encoded namespace ID to prove")
+	fs.StringVar(&rangeArg, "range", "", "range of leaves to prove, as START:END with END exclusive")
+	fs.StringVar(&out, "out", "-", "file to write the proof to, - for the standard output")
+	path, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if (nsHex == "") == (rangeArg == "") {
+		return usageErrorf("exactly one of -namespace and -range must be set")
+	}
+
+	tree, err := tf.tree(path, stdin)
+	if err != nil {
+		return err
+	}
+	var proof nmt.Proof
+	if nsHex != "" {
+		nID, err := parseNamespace(nsHex, tf.nsSize)
+		if err != nil {
+			return err
+		}
+		proof, err = tree.ProveNamespace(nID)
+		if err != nil {
+			return err
+		}
+	} else {
+		start, end, err := parseRange(rangeArg)
+		if err != nil {
+			return err
+		}
+		proof, err = tree.ProveRange(start, end)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := proof.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if out == "-" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
+
+func runVerify(args []string, stdin io.Reader, stdout io.Writer) error {
+	var tf treeFlags
+	var rootHex, proofPath, nsHex string
+	fs := newFlagSet("verify", "[flags] -root ROOT -proof PROOF [-namespace NID] LEAVES")
+	tf.register(fs, false)
+	fs.StringVar(&rootHex, "root", "", "hex encoded root of the tree")
+	fs.StringVar(&proofPath, "proof", "", "file of the JSON encoded proof")
+	fs.StringVar(&nsHex, "namespace", "", "hex encoded namespace ID of a namespace proof; verifies a range proof if unset")
+	path, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if rootHex == "" || proofPath == "" {
+		return usageErrorf("-root and -proof must be set")
+	}
+	root, err := hex.DecodeString(rootHex)
+	if err != nil {
+		return usageErrorf("invalid root: %w", err)
+	}
+	data, err := os.ReadFile(proofPath)
+	if err != nil {
+		return err
+	}
+	var proof nmt.Proof
+	if err := proof.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("failed to decode proof: %w", err)
+	}
+	h, err := tf.hash()
+	if err != nil {
+		return err
+	}
+	leaves, err := tf.readLeaves(path, stdin)
+	if err != nil {
+		return err
+	}
+
+	if nsHex != "" {
+		nID, err := parseNamespace(nsHex, tf.nsSize)
+		if err != nil {
+			return err
+		}
+		if err := proof.VerifyNamespaceErr(h, nID, leaves, root); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidProof, err)
+		}
+	} else if err := verifyRange(h, tf.nsSize, proof, leaves, root); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidProof, err)
+	}
+	_, err = fmt.Fprintln(stdout, "proof is valid")
+	return err
+}
+
+// verifyRange verifies the inclusion of the leaves in the range of the proof,
+// which, unlike Proof.VerifyInclusion, may span several namespaces. If the
+// proof is bound to the size of its tree, the shape of that tree, e.g., the
+// number of proof nodes, is verified as well.
+func verifyRange(h hash.Hash, nsSize int, proof nmt.Proof, leaves [][]byte, root []byte) error {
+	if proof.IsEmptyProof() {
+		return fmt.Errorf("%w: empty proof of a range of leaves", nmt.ErrInvalidRange)
+	}
+	if proof.TreeSize() != 0 {
+		if nID, ok := commonNamespace(leaves, nsSize); ok {
+			leavesWithoutNamespace := make([][]byte, len(leaves))
+			for i, leaf := range leaves {
+				leavesWithoutNamespace[i] = leaf[nsSize:]
+			}
+			return proof.VerifyInclusionErr(h, nID, leavesWithoutNamespace, root)
+		}
+	}
+	if len(leaves) != proof.End()-proof.Start() {
+		return fmt.Errorf("supplied %d leaves, expected %d: %w", len(leaves), proof.End()-proof.Start(), nmt.ErrWrongLeafHashesSize)
+	}
+	nth := nmt.NewNmtHasher(h, namespace.IDSize(nsSize), proof.IsMaxNamespaceIDIgnored())
+	leafHashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		leafHash, err := nth.HashLeaf(leaf)
+		if err != nil {
+			return fmt.Errorf("leaf %d: %w", i, err)
+		}
+		leafHashes[i] = leafHash
+	}
+
+	var ok bool
+	var err error
+	if proof.TreeSize() != 0 {
+		ranges := make([]nmt.LeafRange, len(leaves))
+		for i := range ranges {
+			ranges[i] = nmt.LeafRange{Start: proof.Start() + i, End: proof.Start() + i + 1}
+		}
+		ok, err = proof.VerifySubtreeRoots(nth, leafHashes, ranges, proof.TreeSize(), root)
+	} else {
+		indices := make([]int, 0, len(leaves))
+		for i := proof.Start(); i < proof.End(); i++ {
+			indices = append(indices, i)
+		}
+		ok, err = nmt.NewMultiProof(indices, proof.Nodes(), proof.IsMaxNamespaceIDIgnored()).VerifyLeafHashes(nth, leafHashes, root)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nmt.ErrRootMismatch
+	}
+	return nil
+}
+
+// commonNamespace returns the namespace ID of the leaves, and whether they all
+// have the same one.
+func commonNamespace(leaves [][]byte, nsSize int) (namespace.ID, bool) {
+	if len(leaves) == 0 || len(leaves[0]) < nsSize {
+		return nil, false
+	}
+	nID := namespace.ID(leaves[0][:nsSize])
+	for _, leaf := range leaves[1:] {
+		if len(leaf) < nsSize || !nID.Equal(leaf[:nsSize]) {
+			return nil, false
+		}
+	}
+	return nID, true
+}
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: nmt %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags of a command and returns its single positional
+// argument, the path of the leaves file.
+func parseArgs(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return "", err
+		}
+		return "", usageError{err}
+	}
+	if fs.NArg() != 1 {
+		return "", usageErrorf("expected a single leaves file, got %d arguments", fs.NArg())
+	}
+	return fs.Arg(0), nil
+}
+
+func parseNamespace(s string, size int) (namespace.ID, error) {
+	nID, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, usageErrorf("invalid namespace: %w", err)
+	}
+	if len(nID) != size {
+		return nil, usageErrorf("namespace %s has %d bytes, expected %d", s, len(nID), size)
+	}
+	return nID, nil
+}
+
+func parseRange(s string) (int, int, error) {
+	startStr, endStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, usageErrorf("invalid range %q: expected START:END", s)
+	}
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, usageErrorf("invalid range start: %w", err)
+	}
+	end, err := strconv.Atoi(endStr)
+	if err != nil {
+		return 0, 0, usageErrorf("invalid range end: %w", err)
+	}
+	return start, end, nil
+}
+
+func readHexLeaves(r io.Reader) ([][]byte, error) {
+	var leaves [][]byte
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		leaf, err := hex.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, scanner.Err()
+}
+
+func readBinaryLeaves(r io.Reader) ([][]byte, error) {
+	var leaves [][]byte
+	br := bufio.NewReader(r)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			return leaves, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("leaf %d: failed to read length: %w", len(leaves), err)
+		}
+		if size > 64<<20 {
+			return nil, fmt.Errorf("leaf %d: length %d is too large", len(leaves), size)
+		}
+		leaf := make([]byte, size)
+		if _, err := io.ReadFull(br, leaf); err != nil {
+			return nil, fmt.Errorf("leaf %d: %w", len(leaves), err)
+		}
+		leaves = append(leaves, leaf)
+	}
+}
+
+func hashNames() []string {
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
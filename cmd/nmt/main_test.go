@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt"
+)
+
+// testLeaves returns 8 namespaced leaves with 1 byte namespace IDs 1, 2, 3, 3,
+// 4, 5, 6 and 7.
+func testLeaves() [][]byte {
+	var leaves [][]byte
+	for i, nID := range []byte{1, 2, 3, 3, 4, 5, 6, 7} {
+		leaves = append(leaves, append([]byte{nID}, fmt.Sprintf("leaf_%d", i)...))
+	}
+	return leaves
+}
+
+func writeHexLeaves(t *testing.T, dir, name string, leaves [][]byte) string {
+	var b strings.Builder
+	for _, leaf := range leaves {
+		b.WriteString(hex.EncodeToString(leaf) + "\n")
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o644))
+	return path
+}
+
+func runCmd(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRoot(t *testing.T) {
+	leaves := testLeaves()
+	tree := nmt.New(sha256.New(), nmt.NamespaceIDSize(1))
+	for _, leaf := range leaves {
+		require.NoError(t, tree.Push(leaf))
+	}
+	root, err := tree.Root()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	hexPath := writeHexLeaves(t, dir, "leaves.hex", leaves)
+	var bin []byte
+	for _, leaf := range leaves {
+		bin = binary.AppendUvarint(bin, uint64(len(leaf)))
+		bin = append(bin, leaf...)
+	}
+	binPath := filepath.Join(dir, "leaves.bin")
+	require.NoError(t, os.WriteFile(binPath, bin, 0o644))
+
+	code, stdout, stderr := runCmd(t, "root", "-ns-size", "1", hexPath)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, hex.EncodeToString(root)+"\n", stdout)
+
+	code, stdout, stderr = runCmd(t, "root", "-ns-size", "1", "-format", "binary", binPath)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, hex.EncodeToString(root)+"\n", stdout)
+
+	// sha512 and a different namespace size yield a different root
+	code, stdout, stderr = runCmd(t, "root", "-ns-size", "1", "-hash", "sha512", hexPath)
+	require.Equal(t, 0, code, stderr)
+	assert.NotEqual(t, hex.EncodeToString(root)+"\n", stdout)
+}
+
+func TestProveAndVerify(t *testing.T) {
+	leaves := testLeaves()
+	dir := t.TempDir()
+	leavesPath := writeHexLeaves(t, dir, "leaves.hex", leaves)
+	code, stdout, stderr := runCmd(t, "root", "-ns-size", "1", leavesPath)
+	require.Equal(t, 0, code, stderr)
+	root := strings.TrimSpace(stdout)
+
+	tamperedLeaf := append([]byte{3}, "tampered"...)
+	tests := []struct {
+		name        string
+		proveArgs   []string
+		verifyArgs  []string
+		leaves      [][]byte
+		wantCode    int
+		wantMessage string
+	}{
+		{"namespace", []string{"-namespace", "03"}, []string{"-namespace", "03"}, leaves[2:4], 0, "proof is valid"},
+		{"absent namespace", []string{"-namespace", "08"}, []string{"-namespace", "08"}, nil, 0, "proof is valid"},
+		{"range", []string{"-range", "1:5"}, nil, leaves[1:5], 0, "proof is valid"},
+		{"tampered namespace leaf", []string{"-namespace", "03"}, []string{"-namespace", "03"}, [][]byte{leaves[2], tamperedLeaf}, 1, nmt.ErrRootMismatch.Error()},
+		{"incomplete namespace", []string{"-range", "2:3"}, []string{"-namespace", "03"}, leaves[2:3], 1, nmt.ErrFailedCompletenessCheck.Error()},
+		{"tampered range leaf", []string{"-range", "1:5"}, nil, append(leaves[1:4:4], tamperedLeaf), 1, nmt.ErrRootMismatch.Error()},
+		{"missing range leaf", []string{"-range", "1:5"}, nil, leaves[1:4], 1, nmt.ErrWrongLeafHashesSize.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proofPath := filepath.Join(t.TempDir(), "proof.json")
+			args := append([]string{"prove", "-ns-size", "1", "-out", proofPath}, tt.proveArgs...)
+			code, _, stderr := runCmd(t, append(args, leavesPath)...)
+			require.Equal(t, 0, code, stderr)
+
+			provenPath := writeHexLeaves(t, t.TempDir(), "proven.hex", tt.leaves)
+			args = append([]string{"verify", "-ns-size", "1", "-root", root, "-proof", proofPath}, tt.verifyArgs...)
+			code, stdout, stderr := runCmd(t, append(args, provenPath)...)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stdout+stderr, tt.wantMessage)
+		})
+	}
+}
+
+func TestProve_Stdout(t *testing.T) {
+	leavesPath := writeHexLeaves(t, t.TempDir(), "leaves.hex", testLeaves())
+	code, stdout, stderr := runCmd(t, "prove", "-ns-size", "1", "-range", "0:1", leavesPath)
+	require.Equal(t, 0, code, stderr)
+
+	var proof nmt.Proof
+	require.NoError(t, proof.UnmarshalJSON([]byte(stdout)))
+	assert.Equal(t, 0, proof.Start())
+	assert.Equal(t, 1, proof.End())
+}
+
+func TestRun_Errors(t *testing.T) {
+	dir := t.TempDir()
+	leavesPath := writeHexLeaves(t, dir, "leaves.hex", testLeaves())
+	unorderedPath := writeHexLeaves(t, dir, "unordered.hex", [][]byte{{2, 0}, {1, 0}})
+	invalidHexPath := filepath.Join(dir, "invalid.hex")
+	require.NoError(t, os.WriteFile(invalidHexPath, []byte("0102\nzz\n"), 0o644))
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{"no command", nil, 2},
+		{"unknown command", []string{"frobnicate"}, 2},
+		{"help", []string{"help"}, 0},
+		{"command help", []string{"root", "-h"}, 0},
+		{"unknown flag", []string{"root", "-foo", leavesPath}, 2},
+		{"missing leaves file", []string{"root"}, 2},
+		{"unknown hash", []string{"root", "-hash", "md5", leavesPath}, 2},
+		{"unknown format", []string{"root", "-format", "csv", leavesPath}, 2},
+		{"nonexistent leaves file", []string{"root", filepath.Join(dir, "nope")}, 1},
+		{"invalid hex", []string{"root", "-ns-size", "1", invalidHexPath}, 1},
+		{"unordered leaves", []string{"root", "-ns-size", "1", unorderedPath}, 1},
+		{"prove without namespace or range", []string{"prove", "-ns-size", "1", leavesPath}, 2},
+		{"prove with namespace and range", []string{"prove", "-ns-size", "1", "-namespace", "01", "-range", "0:1", leavesPath}, 2},
+		{"prove namespace of wrong size", []string{"prove", "-ns-size", "1", "-namespace", "0101", leavesPath}, 2},
+		{"prove invalid range syntax", []string{"prove", "-ns-size", "1", "-range", "1-2", leavesPath}, 2},
+		{"prove out of bounds range", []string{"prove", "-ns-size", "1", "-range", "0:9", leavesPath}, 1},
+		{"verify without root", []string{"verify", "-proof", leavesPath, leavesPath}, 2},
+		{"verify invalid proof file", []string{"verify", "-ns-size", "1", "-root", "00", "-proof", leavesPath, leavesPath}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := runCmd(t, tt.args...)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+}
+
+func TestVerify_TamperedNodeCount(t *testing.T) {
+	leaves := testLeaves()
+	dir := t.TempDir()
+	leavesPath := writeHexLeaves(t, dir, "leaves.hex", leaves)
+	code, stdout, stderr := runCmd(t, "root", "-ns-size", "1", leavesPath)
+	require.Equal(t, 0, code, stderr)
+	root := strings.TrimSpace(stdout)
+
+	// a range of a single namespace, and one spanning several namespaces
+	for _, r := range [][2]int{{2, 4}, {1, 5}} {
+		t.Run(fmt.Sprintf("range=%d:%d", r[0], r[1]), func(t *testing.T) {
+			proofPath := filepath.Join(t.TempDir(), "proof.json")
+			code, _, stderr := runCmd(t, "prove", "-ns-size", "1", "-out", proofPath, "-range", fmt.Sprintf("%d:%d", r[0], r[1]), leavesPath)
+			require.Equal(t, 0, code, stderr)
+			data, err := os.ReadFile(proofPath)
+			require.NoError(t, err)
+			var proof nmt.Proof
+			require.NoError(t, proof.UnmarshalJSON(data))
+			require.Equal(t, len(leaves), proof.TreeSize())
+
+			// the proof nodes are followed by a spurious one
+			nodes := append(proof.Nodes(), proof.Nodes()[len(proof.Nodes())-1])
+			tampered := nmt.NewInclusionProof(proof.Start(), proof.End(), nodes, proof.IsMaxNamespaceIDIgnored()).WithTreeSize(proof.TreeSize())
+			data, err = tampered.MarshalJSON()
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(proofPath, data, 0o644))
+
+			provenPath := writeHexLeaves(t, t.TempDir(), "proven.hex", leaves[r[0]:r[1]])
+			code, stdout, stderr = runCmd(t, "verify", "-ns-size", "1", "-root", root, "-proof", proofPath, provenPath)
+			assert.Equal(t, 1, code)
+			assert.Contains(t, stdout+stderr, nmt.ErrInvalidNodeCount.Error())
+		})
+	}
+}
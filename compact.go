@@ -0,0 +1,190 @@
+package nmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/celestiaorg/nmt/namespace"
+	"github.com/celestiaorg/nmt/pb"
+)
+
+// ProofToCompactProto converts a proof, whose nodes have namespace IDs of
+// nIDSize bytes, to the compact protobuf representation pb.CompactProof.
+// Unlike pb.Proof, the namespace IDs of the nodes and of the leaf hash are not
+// encoded in full: the leading bytes a namespace ID shares with the previous
+// one, or with the maximum namespace ID, are dropped and restored by the
+// decoder. The nodes of a proof are ordered by namespace ID, and namespace
+// IDs, e.g., those of Celestia, often start with zeros, so that most of the
+// namespace bytes of a proof are dropped. ProofToCompactProto returns an
+// ErrInvalidNodeFormat error if the nodes and the leaf hash do not all have
+// the same size, larger than 2*nIDSize.
+func ProofToCompactProto(proof Proof, nIDSize namespace.IDSize) (pb.CompactProof, error) {
+	hashes := proof.nodes
+	if len(proof.leafHash) != 0 {
+		hashes = append(hashes[:len(hashes):len(hashes)], proof.leafHash)
+	}
+	nsLen := int(nIDSize)
+	digestSize := 0
+	if len(hashes) > 0 {
+		digestSize = len(hashes[0]) - 2*nsLen
+	}
+
+	maxPossibleNID := bytes.Repeat([]byte{0xFF}, nsLen)
+	prevNID := make([]byte, nsLen)
+	namespaces := make([]byte, 0, len(hashes)*2)
+	digests := make([]byte, 0, len(hashes)*max(digestSize, 0))
+	for i, hash := range hashes {
+		if digestSize <= 0 || len(hash) != 2*nsLen+digestSize {
+			return pb.CompactProof{}, fmt.Errorf("%w: node %d of %d bytes, expected %d bytes", ErrInvalidNodeFormat, i, len(hash), 2*nsLen+max(digestSize, 1))
+		}
+		minNID, maxNID := hash[:nsLen], hash[nsLen:2*nsLen]
+		namespaces = appendCompactNamespace(namespaces, minNID, prevNID, maxPossibleNID)
+		namespaces = appendCompactNamespace(namespaces, maxNID, minNID, maxPossibleNID)
+		prevNID = maxNID
+		digests = append(digests, hash[2*nsLen:]...)
+	}
+
+	return pb.CompactProof{
+		Start:                 uint64(proof.start),
+		End:                   uint64(proof.end),
+		TreeSize:              uint64(proof.treeSize),
+		IsMaxNamespaceIgnored: proof.isMaxNamespaceIDIgnored,
+		HasLeafHash:           len(proof.leafHash) != 0,
+		NamespaceSize:         uint32(nIDSize),
+		DigestSize:            uint32(digestSize),
+		Namespaces:            namespaces,
+		Digests:               digests,
+	}, nil
+}
+
+// appendCompactNamespace appends the compact encoding of the namespace ID nID
+// to buf, see pb.CompactProof: the bytes of nID that follow its longest
+// common prefix with either prevNID or maxPossibleNID, preceded by a varint
+// of twice the length of the prefix, plus one if it is shared with
+// maxPossibleNID.
+func appendCompactNamespace(buf, nID, prevNID, maxPossibleNID []byte) []byte {
+	prefixLen, ref := commonPrefixLen(nID, prevNID), 0
+	if maxPrefixLen := commonPrefixLen(nID, maxPossibleNID); maxPrefixLen > prefixLen {
+		prefixLen, ref = maxPrefixLen, 1
+	}
+	buf = binary.AppendUvarint(buf, uint64(2*prefixLen+ref))
+	return append(buf, nID[prefixLen:]...)
+}
+
+func commonPrefixLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// CompactProtoToProof creates a proof from its compact protobuf
+// representation, see ProofToCompactProto. It returns an ErrInvalidNodeFormat
+// error if the namespaces or the digests are malformed, and otherwise
+// validates the proof like ProtoToProofStrict.
+func CompactProtoToProof(protoProof pb.CompactProof) (Proof, error) {
+	if protoProof.NamespaceSize > math.MaxUint8 {
+		return Proof{}, fmt.Errorf("%w: namespace size %d", ErrInvalidNodeFormat, protoProof.NamespaceSize)
+	}
+	if protoProof.Start > math.MaxInt || protoProof.End > math.MaxInt || protoProof.TreeSize > math.MaxInt {
+		return Proof{}, fmt.Errorf("%w: range [%d, %d) of a tree of size %d", ErrInvalidRange, protoProof.Start, protoProof.End, protoProof.TreeSize)
+	}
+	nsLen, digestSize := int(protoProof.NamespaceSize), int(protoProof.DigestSize)
+	digests := protoProof.Digests
+	if (digestSize == 0 && len(digests) != 0) || (digestSize != 0 && len(digests)%digestSize != 0) {
+		return Proof{}, fmt.Errorf("%w: %d bytes of digests of size %d", ErrInvalidNodeFormat, len(digests), digestSize)
+	}
+	count := 0
+	if digestSize != 0 {
+		count = len(digests) / digestSize
+	}
+	if protoProof.HasLeafHash && count == 0 {
+		return Proof{}, fmt.Errorf("%w: missing leaf hash", ErrInvalidNodeFormat)
+	}
+	// every namespace ID takes at least one byte
+	if len(protoProof.Namespaces) < 2*count {
+		return Proof{}, fmt.Errorf("%w: %d bytes of namespace IDs for %d nodes", ErrInvalidNodeFormat, len(protoProof.Namespaces), count)
+	}
+
+	nodeSize := 2*nsLen + digestSize
+	buf := make([]byte, count*nodeSize)
+	hashes := make([][]byte, count)
+	maxPossibleNID := bytes.Repeat([]byte{0xFF}, nsLen)
+	prevNID := make([]byte, nsLen)
+	namespaces := protoProof.Namespaces
+	for i := range hashes {
+		hash := buf[i*nodeSize : (i+1)*nodeSize : (i+1)*nodeSize]
+		for j := 0; j < 2; j++ {
+			v, n := binary.Uvarint(namespaces)
+			if n <= 0 || v/2 > uint64(nsLen) {
+				return Proof{}, fmt.Errorf("%w: malformed namespace ID of node %d", ErrInvalidNodeFormat, i)
+			}
+			prefixLen, ref := int(v/2), prevNID
+			if v%2 == 1 {
+				ref = maxPossibleNID
+			}
+			suffix := namespaces[n:]
+			if len(suffix) < nsLen-prefixLen {
+				return Proof{}, fmt.Errorf("%w: truncated namespace ID of node %d", ErrInvalidNodeFormat, i)
+			}
+			nID := hash[j*nsLen : (j+1)*nsLen]
+			copy(nID, ref[:prefixLen])
+			copy(nID[prefixLen:], suffix[:nsLen-prefixLen])
+			namespaces = suffix[nsLen-prefixLen:]
+			prevNID = nID
+		}
+		copy(hash[2*nsLen:], digests[i*digestSize:(i+1)*digestSize])
+		hashes[i] = hash
+	}
+	if len(namespaces) != 0 {
+		return Proof{}, fmt.Errorf("%w: %d trailing bytes of namespace IDs", ErrInvalidNodeFormat, len(namespaces))
+	}
+
+	nodes := hashes
+	var leafHash []byte
+	if protoProof.HasLeafHash {
+		nodes, leafHash = hashes[:count-1], hashes[count-1]
+	}
+	if len(nodes) == 0 {
+		nodes = nil
+	}
+	return ProtoToProofStrict(pb.Proof{
+		Start:                 int64(protoProof.Start),
+		End:                   int64(protoProof.End),
+		Nodes:                 nodes,
+		LeafHash:              leafHash,
+		IsMaxNamespaceIgnored: protoProof.IsMaxNamespaceIgnored,
+		TreeSize:              int64(protoProof.TreeSize),
+	})
+}
+
+// MarshalCompact encodes the proof, whose nodes have namespace IDs of nIDSize
+// bytes, in the binary protobuf format of pb.CompactProof, see
+// ProofToCompactProto.
+func (proof Proof) MarshalCompact(nIDSize namespace.IDSize) ([]byte, error) {
+	pbProof, err := ProofToCompactProto(proof, nIDSize)
+	if err != nil {
+		return nil, err
+	}
+	return pbProof.Marshal()
+}
+
+// UnmarshalCompact decodes a proof encoded by MarshalCompact, see
+// CompactProtoToProof.
+func (proof *Proof) UnmarshalCompact(data []byte) error {
+	var pbProof pb.CompactProof
+	if err := pbProof.Unmarshal(data); err != nil {
+		return err
+	}
+	decoded, err := CompactProtoToProof(pbProof)
+	if err != nil {
+		return err
+	}
+	*proof = decoded
+	return nil
+}
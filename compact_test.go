@@ -0,0 +1,198 @@
+package nmt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+	"github.com/celestiaorg/nmt/pb"
+)
+
+// celestiaRow returns a tree of numLeaves leaves like a row of a Celestia
+// extended data square: the first half of the leaves have random version 0
+// namespaces of 29 bytes, i.e., a zero version byte, 18 zero bytes and 10
+// random bytes, and the second half, the parity leaves, have the maximum
+// namespace.
+func celestiaRow(t testing.TB, numLeaves int) *NamespacedMerkleTree {
+	const nidSize = 29
+	nIDs := make([][]byte, numLeaves)
+	for i := range nIDs {
+		nIDs[i] = bytes.Repeat([]byte{0xFF}, nidSize)
+		if i < numLeaves/2 {
+			nIDs[i] = make([]byte, nidSize)
+			_, err := rand.Read(nIDs[i][nidSize-10:])
+			require.NoError(t, err)
+		}
+	}
+	sortByteArrays(nIDs[:numLeaves/2])
+	tree := New(sha256.New(), NamespaceIDSize(nidSize), IgnoreMaxNamespace(true))
+	for _, nID := range nIDs {
+		require.NoError(t, tree.Push(append(nID, bytes.Repeat([]byte{1}, 478)...)))
+	}
+	return tree
+}
+
+func TestProof_MarshalCompact(t *testing.T) {
+	tree := New(sha256.New(), NamespaceIDSize(2))
+	for i, nID := range [][]byte{{0, 0}, {0, 1}, {0, 3}, {0, 3}, {1, 4}, {0xFF, 0xFF}, {0xFF, 0xFF}, {0xFF, 0xFF}} {
+		require.NoError(t, tree.Push(append(nID, fmt.Sprintf("leaf_%d", i)...)))
+	}
+	inclusion, err := tree.ProveNamespace(namespace.ID{0, 3})
+	require.NoError(t, err)
+	absence, err := tree.ProveNamespace(namespace.ID{0, 2})
+	require.NoError(t, err)
+	require.True(t, absence.IsOfAbsence())
+	parity, err := tree.ProveRange(6, 8)
+	require.NoError(t, err)
+	row := celestiaRow(t, 128)
+	rowProof, err := row.ProveNamespace(row.leaves[10][:29])
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		proof   Proof
+		nIDSize namespace.IDSize
+	}{
+		{"inclusion proof", inclusion, 2},
+		{"absence proof", absence, 2},
+		{"proof of parity leaves", parity, 2},
+		{"proof without tree size", inclusion.WithTreeSize(0), 2},
+		{"proof of all the leaves", NewInclusionProof(0, 8, nil, false).WithTreeSize(8), 2},
+		{"empty proof", NewEmptyRangeProof(true), 2},
+		{"celestia row", rowProof, 29},
+		{"no namespace", NewInclusionProof(0, 1, [][]byte{{1, 2, 3}}, false), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.proof.MarshalCompact(tt.nIDSize)
+			require.NoError(t, err)
+			var decoded Proof
+			require.NoError(t, decoded.UnmarshalCompact(data))
+			assert.Equal(t, tt.proof, decoded)
+		})
+	}
+
+	// most of the namespace bytes of the proofs of Celestia rows are dropped
+	root, err := row.Root()
+	require.NoError(t, err)
+	data, err := rowProof.MarshalCompact(29)
+	require.NoError(t, err)
+	binary, err := rowProof.Marshal()
+	require.NoError(t, err)
+	nodes := len(rowProof.Nodes())
+	assert.Less(t, len(data), len(binary)-nodes*29, "compact: %d bytes, binary: %d bytes", len(data), len(binary))
+	var decoded Proof
+	require.NoError(t, decoded.UnmarshalCompact(data))
+	assert.True(t, decoded.VerifyNamespace(sha256.New(), row.leaves[10][:29], row.Get(row.leaves[10][:29]), root))
+}
+
+func TestProofToCompactProto_Errors(t *testing.T) {
+	node := bytes.Repeat([]byte{1}, 34)
+	tests := []struct {
+		name  string
+		proof Proof
+	}{
+		{"nodes of different sizes", NewInclusionProof(0, 1, [][]byte{node, node[1:]}, true)},
+		{"leaf hash of a different size", NewAbsenceProof(0, 1, [][]byte{node}, node[1:], true)},
+		{"node without digest", NewInclusionProof(0, 1, [][]byte{node[:2]}, true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.proof.MarshalCompact(1)
+			assert.ErrorIs(t, err, ErrInvalidNodeFormat)
+		})
+	}
+}
+
+func TestCompactProtoToProof_Errors(t *testing.T) {
+	proof := NewAbsenceProof(1, 2, [][]byte{{0, 1, 7}, {2, 2, 7}}, []byte{1, 2, 7}, true).WithTreeSize(4)
+	valid, err := ProofToCompactProto(proof, 1)
+	require.NoError(t, err)
+	decoded, err := CompactProtoToProof(valid)
+	require.NoError(t, err)
+	require.Equal(t, proof, decoded)
+
+	tests := []struct {
+		name    string
+		modify  func(p *pb.CompactProof)
+		wantErr error
+	}{
+		{"digests not a multiple of the digest size", func(p *pb.CompactProof) { p.DigestSize = 2 }, ErrInvalidNodeFormat},
+		{"digests without digest size", func(p *pb.CompactProof) { p.DigestSize = 0 }, ErrInvalidNodeFormat},
+		{"missing leaf hash", func(p *pb.CompactProof) { p.Digests, p.Namespaces = nil, nil }, ErrInvalidNodeFormat},
+		{"truncated namespaces", func(p *pb.CompactProof) { p.Namespaces = p.Namespaces[:len(p.Namespaces)-1] }, ErrInvalidNodeFormat},
+		{"trailing namespaces", func(p *pb.CompactProof) { p.Namespaces = append(p.Namespaces, 0) }, ErrInvalidNodeFormat},
+		{"prefix longer than the namespace", func(p *pb.CompactProof) { p.Namespaces[0] = 4 }, ErrInvalidNodeFormat},
+		{"too few namespaces", func(p *pb.CompactProof) { p.Namespaces = p.Namespaces[:2] }, ErrInvalidNodeFormat},
+		{"namespace size too large", func(p *pb.CompactProof) { p.NamespaceSize = 256 }, ErrInvalidNodeFormat},
+		{"end too large", func(p *pb.CompactProof) { p.End = 1 << 63 }, ErrInvalidRange},
+		{"range beyond the tree", func(p *pb.CompactProof) { p.TreeSize = 1 }, ErrInvalidRange},
+		{"absence proof of several leaves", func(p *pb.CompactProof) { p.End = 3 }, ErrInvalidRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := valid
+			modified.Namespaces = bytes.Clone(valid.Namespaces)
+			tt.modify(&modified)
+			_, err := CompactProtoToProof(modified)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			data, err := modified.Marshal()
+			require.NoError(t, err)
+			var decoded Proof
+			assert.ErrorIs(t, decoded.UnmarshalCompact(data), tt.wantErr)
+		})
+	}
+}
+
+// BenchmarkProofEncoding reports the size of the binary and the compact
+// encodings of the proofs of every namespace of Celestia-like rows, in bytes
+// per proof.
+func BenchmarkProofEncoding(b *testing.B) {
+	for _, numLeaves := range []int{128, 256, 512} {
+		row := celestiaRow(b, numLeaves)
+		var proofs []Proof
+		for _, leaf := range row.leaves[:numLeaves/2] {
+			proof, err := row.ProveNamespace(leaf[:29])
+			require.NoError(b, err)
+			proofs = append(proofs, proof)
+		}
+		// a proof of the absence of a namespace between two leaves
+		absentNID := bytes.Clone(row.leaves[0][:29])
+		absentNID[len(absentNID)-1]++
+		if found, _, _ := row.foundInRange(absentNID); !found {
+			proof, err := row.ProveNamespace(absentNID)
+			require.NoError(b, err)
+			proofs = append(proofs, proof)
+		}
+
+		encodings := []struct {
+			name   string
+			encode func(proof Proof) ([]byte, error)
+		}{
+			{"binary", Proof.Marshal},
+			{"compact", func(proof Proof) ([]byte, error) { return proof.MarshalCompact(29) }},
+		}
+		for _, encoding := range encodings {
+			b.Run(fmt.Sprintf("%d-leaves-%s", numLeaves, encoding.name), func(b *testing.B) {
+				b.ReportAllocs()
+				size := 0
+				for i := 0; i < b.N; i++ {
+					size = 0
+					for _, proof := range proofs {
+						data, err := encoding.encode(proof)
+						require.NoError(b, err)
+						size += len(data)
+					}
+				}
+				b.ReportMetric(float64(size)/float64(len(proofs)), "bytes/proof")
+			})
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package nmt
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"math/bits"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// ConsistencyProof proves that a tree of oldSize leaves is a prefix of a tree
+// of newSize leaves, i.e., that the latter was obtained by appending leaves to
+// the former, as per the Merkle consistency proofs of RFC 6962.
+type ConsistencyProof struct {
+	// oldSize and newSize are the number of leaves of the two trees.
+	oldSize, newSize int
+	// nodes are the namespaced hashes of the subtrees needed to compute both
+	// roots, in the order of RFC 6962: the nodes of the old tree first, then
+	// the nodes on the path to the new root from the smallest to the largest.
+	nodes [][]byte
+	// isMaxNamespaceIDIgnored is set to true if the tree from which this proof
+	// was generated from is initialized with Options.IgnoreMaxNamespace ==
+	// true, see Proof.
+	isMaxNamespaceIDIgnored bool
+}
+
+// NewConsistencyProof constructs a proof that a tree of oldSize leaves is a
+// prefix of a tree of newSize leaves.
+func NewConsistencyProof(oldSize, newSize int, proofNodes [][]byte, ignoreMaxNamespace bool) ConsistencyProof {
+	return ConsistencyProof{
+		oldSize:                 oldSize,
+		newSize:                 newSize,
+		nodes:                   proofNodes,
+		isMaxNamespaceIDIgnored: ignoreMaxNamespace,
+	}
+}
+
+// OldSize returns the number of leaves of the old tree.
+func (proof ConsistencyProof) OldSize() int {
+	return proof.oldSize
+}
+
+// NewSize returns the number of leaves of the new tree.
+func (proof ConsistencyProof) NewSize() int {
+	return proof.newSize
+}
+
+// Nodes returns the proof nodes.
+func (proof ConsistencyProof) Nodes() [][]byte {
+	return proof.nodes
+}
+
+// IsMaxNamespaceIDIgnored returns true if the proof has been created under the
+// ignore max namespace logic, see Proof.IsMaxNamespaceIDIgnored.
+func (proof ConsistencyProof) IsMaxNamespaceIDIgnored() bool {
+	return proof.isMaxNamespaceIDIgnored
+}
+
+// ProveConsistency returns a proof that the tree as it was when it had oldSize
+// leaves is a prefix of the current tree, see ConsistencyProof. An append-only
+// log can hand it out to show that its history was not rewritten between two
+// roots. ProveConsistency returns an ErrInvalidRange error if oldSize is
+// negative or larger than the size of the tree. Any other error is
+// irrecoverable and indicates an illegal state of the tree (n).
+func (n *NamespacedMerkleTree) ProveConsistency(oldSize int) (ConsistencyProof, error) {
+	return n.ProveConsistencyBetween(oldSize, n.Size())
+}
+
+// ProveConsistencyBetween is like ProveConsistency, but proves that the tree
+// of the first oldSize leaves is a prefix of the tree of the first newSize
+// leaves, so that the consistency of any two roots published by a log can be
+// proven after more leaves were pushed. It returns an ErrInvalidRange error
+// unless 0 <= oldSize <= newSize <= n.Size().
+func (n *NamespacedMerkleTree) ProveConsistencyBetween(oldSize, newSize int) (ConsistencyProof, error) {
+	if newSize < 0 || newSize > n.Size() {
+		return ConsistencyProof{}, fmt.Errorf("%w: new size %d is not in [0, %d]", ErrInvalidRange, newSize, n.Size())
+	}
+	if oldSize < 0 || oldSize > newSize {
+		return ConsistencyProof{}, fmt.Errorf("%w: old size %d is not in [0, %d]", ErrInvalidRange, oldSize, newSize)
+	}
+	proof := NewConsistencyProof(oldSize, newSize, nil, n.treeHasher.IsMaxNamespaceIDIgnored())
+	if oldSize == 0 || oldSize == newSize {
+		return proof, nil
+	}
+	nodes, err := n.consistencySubproof(oldSize, 0, newSize, true)
+	if err != nil {
+		return ConsistencyProof{}, err
+	}
+	proof.nodes = nodes
+	return proof, nil
+}
+
+// consistencySubproof returns the nodes of the consistency proof of the first
+// m leaves of the subtree of the leaves in [start, end), as per the SUBPROOF
+// algorithm of RFC 6962. complete reports whether the leaves [start, start+m)
+// form a complete subtree of the old tree, whose root the verifier already
+// knows.
+func (n *NamespacedMerkleTree) consistencySubproof(m, start, end int, complete bool) ([][]byte, error) {
+	if m == end-start {
+		if complete {
+			return nil, nil
+		}
+		hash, err := n.nodeHash(start, end)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{hash}, nil
+	}
+	k := getSplitPoint(end - start)
+	if m <= k {
+		nodes, err := n.consistencySubproof(m, start, start+k, complete)
+		if err != nil {
+			return nil, err
+		}
+		right, err := n.nodeHash(start+k, end)
+		if err != nil {
+			return nil, err
+		}
+		return append(nodes, right), nil
+	}
+	nodes, err := n.consistencySubproof(m-k, start+k, end, false)
+	if err != nil {
+		return nil, err
+	}
+	left, err := n.nodeHash(start, start+k)
+	if err != nil {
+		return nil, err
+	}
+	return append(nodes, left), nil
+}
+
+// VerifyConsistency checks that the tree of root oldRoot is a prefix of the
+// tree of root newRoot, using the hash function h and namespaces of nIDSize
+// bytes. Besides the roots computed from the proof, it checks that the
+// namespace ordering of the leaves carries over the boundary between the old
+// leaves and the appended ones: the old tree has the same minimum namespace as
+// the new one and no larger maximum namespace, and every node of the old tree
+// is ordered before the appended nodes it is hashed with.
+func (proof ConsistencyProof) VerifyConsistency(h hash.Hash, nIDSize namespace.IDSize, oldRoot, newRoot []byte) bool {
+	return proof.VerifyConsistencyErr(h, nIDSize, oldRoot, newRoot) == nil
+}
+
+// VerifyConsistencyErr is like VerifyConsistency, but returns an error
+// describing why the verification failed instead of false, and nil if the
+// proof is valid, see Proof.VerifyNamespaceErr.
+func (proof ConsistencyProof) VerifyConsistencyErr(h hash.Hash, nIDSize namespace.IDSize, oldRoot, newRoot []byte) error {
+	nth := NewNmtHasher(h, nIDSize, proof.isMaxNamespaceIDIgnored)
+	if proof.oldSize < 0 || proof.oldSize > proof.newSize {
+		return fmt.Errorf("%w: old size %d is not in [0, %d]", ErrInvalidRange, proof.oldSize, proof.newSize)
+	}
+	if err := nth.ValidateNodeFormat(oldRoot); err != nil {
+		return fmt.Errorf("%w: old root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+	}
+	if err := nth.ValidateNodeFormat(newRoot); err != nil {
+		return fmt.Errorf("%w: new root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+	}
+	for _, node := range proof.nodes {
+		if err := nth.ValidateNodeFormat(node); err != nil {
+			return fmt.Errorf("%w: proof nodes do not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+		}
+	}
+
+	// the empty tree is a prefix of any tree
+	if proof.oldSize == 0 {
+		if len(proof.nodes) != 0 {
+			return fmt.Errorf("%w: got %d proof nodes for an empty old tree", ErrInvalidNodeCount, len(proof.nodes))
+		}
+		if !bytes.Equal(oldRoot, nth.EmptyRoot()) {
+			return fmt.Errorf("%w: old root of an empty tree", ErrRootMismatch)
+		}
+		if proof.newSize == 0 && !bytes.Equal(newRoot, nth.EmptyRoot()) {
+			return fmt.Errorf("%w: new root of an empty tree", ErrRootMismatch)
+		}
+		return nil
+	}
+
+	// the old tree must hold the first leaves of the new one
+	oldMin, oldMax := MinNamespace(oldRoot, nIDSize), MaxNamespace(oldRoot, nIDSize)
+	newMin, newMax := MinNamespace(newRoot, nIDSize), MaxNamespace(newRoot, nIDSize)
+	if !bytes.Equal(oldMin, newMin) || namespace.ID(newMax).Less(oldMax) {
+		return fmt.Errorf("%w: namespace range [%x, %x] of the old root does not extend to the range [%x, %x] of the new root", ErrNamespaceMismatch, oldMin, oldMax, newMin, newMax)
+	}
+
+	if proof.oldSize == proof.newSize {
+		if len(proof.nodes) != 0 {
+			return fmt.Errorf("%w: got %d proof nodes for trees of the same size", ErrInvalidNodeCount, len(proof.nodes))
+		}
+		if !bytes.Equal(oldRoot, newRoot) {
+			return ErrRootMismatch
+		}
+		return nil
+	}
+
+	// the algorithm of RFC 9162, section 2.1.4.2
+	nodes := proof.nodes
+	if bits.OnesCount(uint(proof.oldSize)) == 1 {
+		// the old tree is a complete subtree of the new one
+		nodes = append([][]byte{oldRoot}, nodes...)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("%w: empty consistency proof", ErrInvalidNodeCount)
+	}
+	fn, sn := uint(proof.oldSize-1), uint(proof.newSize-1)
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := nodes[0], nodes[0]
+	var err error
+	for _, c := range nodes[1:] {
+		if sn == 0 {
+			return fmt.Errorf("%w: too many proof nodes", ErrInvalidNodeCount)
+		}
+		if fn&1 == 1 || fn == sn {
+			if fr, err = nth.HashNode(c, fr); err != nil {
+				return fmt.Errorf("failed to hash node: %w", err)
+			}
+			if sr, err = nth.HashNode(c, sr); err != nil {
+				return fmt.Errorf("failed to hash node: %w", err)
+			}
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else if sr, err = nth.HashNode(sr, c); err != nil {
+			return fmt.Errorf("failed to hash node: %w", err)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("%w: too few proof nodes", ErrInvalidNodeCount)
+	}
+	if !bytes.Equal(fr, oldRoot) {
+		return fmt.Errorf("%w: old root", ErrRootMismatch)
+	}
+	if !bytes.Equal(sr, newRoot) {
+		return fmt.Errorf("%w: new root", ErrRootMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package nmt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consistencyTestTree returns a tree with the first size leaves of a sequence
+// of leaves with non-decreasing namespace IDs, ending with leaves of the
+// maximum namespace ID.
+func consistencyTestTree(t *testing.T, size int, ignoreMaxNamespace bool) *NamespacedMerkleTree {
+	tree := New(sha256.New(), NamespaceIDSize(1), IgnoreMaxNamespace(ignoreMaxNamespace))
+	for i := 0; i < size; i++ {
+		nID := byte(i / 3)
+		if i >= 24 {
+			nID = 0xff
+		}
+		require.NoError(t, tree.Push(append([]byte{nID}, fmt.Sprintf("leaf_%d", i)...)))
+	}
+	return tree
+}
+
+func TestProveConsistency(t *testing.T) {
+	for _, ignoreMaxNamespace := range []bool{true, false} {
+		roots := make([][]byte, 0, 34)
+		for size := 0; size <= 33; size++ {
+			root, err := consistencyTestTree(t, size, ignoreMaxNamespace).Root()
+			require.NoError(t, err)
+			roots = append(roots, root)
+		}
+		for newSize := 0; newSize <= 33; newSize++ {
+			tree := consistencyTestTree(t, newSize, ignoreMaxNamespace)
+			for oldSize := 0; oldSize <= newSize; oldSize++ {
+				proof, err := tree.ProveConsistency(oldSize)
+				require.NoError(t, err)
+				assert.Equal(t, oldSize, proof.OldSize())
+				assert.Equal(t, newSize, proof.NewSize())
+				assert.Equal(t, ignoreMaxNamespace, proof.IsMaxNamespaceIDIgnored())
+				err = proof.VerifyConsistencyErr(sha256.New(), 1, roots[oldSize], roots[newSize])
+				require.NoError(t, err, "old size %d, new size %d, ignore max %t", oldSize, newSize, ignoreMaxNamespace)
+
+				// the proof does not hold for other roots
+				for _, otherOld := range []int{oldSize - 1, oldSize + 1} {
+					if otherOld < 0 || otherOld > newSize {
+						continue
+					}
+					assert.False(t, proof.VerifyConsistency(sha256.New(), 1, roots[otherOld], roots[newSize]))
+				}
+				// the empty tree is a prefix of any tree
+				if oldSize > 0 && newSize < 33 {
+					assert.False(t, proof.VerifyConsistency(sha256.New(), 1, roots[oldSize], roots[newSize+1]), "old size %d, new size %d", oldSize, newSize)
+				}
+			}
+		}
+	}
+}
+
+func TestProveConsistency_Errors(t *testing.T) {
+	tree := consistencyTestTree(t, 5, true)
+	for _, oldSize := range []int{-1, 6} {
+		_, err := tree.ProveConsistency(oldSize)
+		assert.ErrorIs(t, err, ErrInvalidRange)
+	}
+}
+
+func TestProveConsistencyBetween(t *testing.T) {
+	tree := consistencyTestTree(t, 20, true)
+	roots := make([][]byte, 0, 21)
+	for size := 0; size <= 20; size++ {
+		root, err := consistencyTestTree(t, size, true).Root()
+		require.NoError(t, err)
+		roots = append(roots, root)
+	}
+	for newSize := 0; newSize <= 20; newSize++ {
+		for oldSize := 0; oldSize <= newSize; oldSize++ {
+			proof, err := tree.ProveConsistencyBetween(oldSize, newSize)
+			require.NoError(t, err)
+			assert.Equal(t, oldSize, proof.OldSize())
+			assert.Equal(t, newSize, proof.NewSize())
+			err = proof.VerifyConsistencyErr(sha256.New(), 1, roots[oldSize], roots[newSize])
+			require.NoError(t, err, "old size %d, new size %d", oldSize, newSize)
+		}
+	}
+	// the nodes of the proofs between prefixes do not alter the tree
+	root, err := tree.Root()
+	require.NoError(t, err)
+	assert.Equal(t, roots[20], root)
+
+	for _, sizes := range [][2]int{{-1, 5}, {6, 5}, {5, 21}} {
+		_, err := tree.ProveConsistencyBetween(sizes[0], sizes[1])
+		assert.ErrorIs(t, err, ErrInvalidRange)
+	}
+}
+
+// TestProveConsistencyBetween_SetLeaf checks that the proofs between older
+// sizes do not use the nodes of the older trees computed before a leaf
+// changed, such as [4, 7), which is not a node of the current tree.
+func TestProveConsistencyBetween_SetLeaf(t *testing.T) {
+	tree := consistencyTestTree(t, 8, true)
+	_, err := tree.ProveConsistencyBetween(3, 7)
+	require.NoError(t, err)
+	require.NoError(t, tree.SetLeaf(5, append([]byte{1}, "new leaf"...)))
+
+	roots := make([][]byte, 0, 2)
+	for _, size := range []int{3, 7} {
+		prefix := New(sha256.New(), NamespaceIDSize(1))
+		for _, leaf := range tree.leaves[:size] {
+			require.NoError(t, prefix.Push(leaf))
+		}
+		root, err := prefix.Root()
+		require.NoError(t, err)
+		roots = append(roots, root)
+	}
+	proof, err := tree.ProveConsistencyBetween(3, 7)
+	require.NoError(t, err)
+	assert.NoError(t, proof.VerifyConsistencyErr(sha256.New(), 1, roots[0], roots[1]))
+}
+
+func TestVerifyConsistency_False(t *testing.T) {
+	oldTree := consistencyTestTree(t, 6, true)
+	oldRoot, err := oldTree.Root()
+	require.NoError(t, err)
+	newTree := consistencyTestTree(t, 11, true)
+	newRoot, err := newTree.Root()
+	require.NoError(t, err)
+	proof, err := newTree.ProveConsistency(6)
+	require.NoError(t, err)
+	require.NoError(t, proof.VerifyConsistencyErr(sha256.New(), 1, oldRoot, newRoot))
+
+	// a tree whose history was rewritten: its first leaf differs
+	rewritten := New(sha256.New(), NamespaceIDSize(1))
+	for i := 0; i < 11; i++ {
+		data := append([]byte{byte(i / 3)}, fmt.Sprintf("leaf_%d", i)...)
+		if i == 0 {
+			data = append([]byte{0}, "rewritten"...)
+		}
+		require.NoError(t, rewritten.Push(data))
+	}
+	rewrittenRoot, err := rewritten.Root()
+	require.NoError(t, err)
+	rewrittenProof, err := rewritten.ProveConsistency(6)
+	require.NoError(t, err)
+
+	// an old tree whose namespaces do not carry over to the new one
+	otherNamespace := New(sha256.New(), NamespaceIDSize(1))
+	for i := 0; i < 6; i++ {
+		require.NoError(t, otherNamespace.Push(append([]byte{7}, fmt.Sprintf("leaf_%d", i)...)))
+	}
+	otherNamespaceRoot, err := otherNamespace.Root()
+	require.NoError(t, err)
+
+	tamperedNodes := slices.Clone(proof.Nodes())
+	tamperedNodes[1] = tamperedNodes[0]
+
+	tests := []struct {
+		name    string
+		proof   ConsistencyProof
+		oldRoot []byte
+		newRoot []byte
+		wantErr error
+	}{
+		{"rewritten history", proof, oldRoot, rewrittenRoot, ErrRootMismatch},
+		{"proof of a rewritten history", rewrittenProof, oldRoot, newRoot, ErrRootMismatch},
+		{"namespaces do not carry over", proof, otherNamespaceRoot, newRoot, ErrNamespaceMismatch},
+		{"tampered node", NewConsistencyProof(6, 11, tamperedNodes, true), oldRoot, newRoot, nil},
+		{"missing node", NewConsistencyProof(6, 11, proof.Nodes()[1:], true), oldRoot, newRoot, nil},
+		{"extra node", NewConsistencyProof(6, 11, append(slices.Clone(proof.Nodes()), proof.Nodes()[0]), true), oldRoot, newRoot, ErrInvalidNodeCount},
+		{"wrong old size", NewConsistencyProof(5, 11, proof.Nodes(), true), oldRoot, newRoot, nil},
+		{"old size larger than new size", NewConsistencyProof(12, 11, proof.Nodes(), true), oldRoot, newRoot, ErrInvalidRange},
+		{"malformed node", NewConsistencyProof(6, 11, [][]byte{{0}}, true), oldRoot, newRoot, ErrInvalidNodeFormat},
+		{"malformed old root", proof, oldRoot[:1], newRoot, ErrInvalidNodeFormat},
+		{"nodes for an empty old tree", NewConsistencyProof(0, 11, proof.Nodes(), true), newTree.treeHasher.EmptyRoot(), newRoot, ErrInvalidNodeCount},
+		{"non-empty old tree of size 0", NewConsistencyProof(0, 11, nil, true), oldRoot, newRoot, ErrRootMismatch},
+		{"non-empty new tree of size 0", NewConsistencyProof(0, 0, nil, true), newTree.treeHasher.EmptyRoot(), newRoot, ErrRootMismatch},
+		{"same size with different roots", NewConsistencyProof(11, 11, nil, true), oldRoot, newRoot, ErrRootMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.proof.VerifyConsistencyErr(sha256.New(), 1, tt.oldRoot, tt.newRoot)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.False(t, tt.proof.VerifyConsistency(sha256.New(), 1, tt.oldRoot, tt.newRoot))
+		})
+	}
+}
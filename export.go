@@ -0,0 +1,188 @@
+package nmt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// exportDigestLen is the number of bytes of the digest of a node shown by the
+// exporters.
+const exportDigestLen = 4
+
+// exportRole is the role of a node of the tree w.r.t. a proof.
+type exportRole int
+
+const (
+	exportRoleNone exportRole = iota
+	// exportRoleProven is a leaf in the range of the proof.
+	exportRoleProven
+	// exportRolePath is an inner node recomputed by the verifier, i.e., a node
+	// overlapping the range of the proof.
+	exportRolePath
+	// exportRoleProofNode is a node included in the proof.
+	exportRoleProofNode
+)
+
+// exportNode is a node of the tree as written by the exporters.
+type exportNode struct {
+	r        LeafRange
+	hash     []byte
+	role     exportRole
+	children []*exportNode
+}
+
+// WriteDOT writes the tree in the Graphviz DOT format to w. Every node is
+// labeled with the range of leaves it covers, its minimum and maximum
+// namespace IDs and the first bytes of its digest. If proof is not nil, the
+// nodes of the proof, the leaves it proves and the nodes recomputed by its
+// verifier are highlighted; the proof must have been generated by the tree.
+func (n *NamespacedMerkleTree) WriteDOT(w io.Writer, proof *Proof) error {
+	root, err := n.exportTree(proof)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph nmt {")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=\"monospace\"];")
+	var write func(node *exportNode)
+	write = func(node *exportNode) {
+		id := fmt.Sprintf("\"%d-%d\"", node.r.Start, node.r.End)
+		label := strings.Join(n.exportLabel(node), "\\n")
+		fmt.Fprintf(bw, "\t%s [label=\"%s\"%s];\n", id, label, dotStyle(node.role))
+		for _, child := range node.children {
+			fmt.Fprintf(bw, "\t%s -> \"%d-%d\";\n", id, child.r.Start, child.r.End)
+			write(child)
+		}
+	}
+	write(root)
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteASCII writes the tree as an indented text diagram to w, one node per
+// line and the left child of every node first. Nodes are labeled and
+// highlighted like in WriteDOT.
+func (n *NamespacedMerkleTree) WriteASCII(w io.Writer, proof *Proof) error {
+	root, err := n.exportTree(proof)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	var write func(node *exportNode, prefix, childPrefix string)
+	write = func(node *exportNode, prefix, childPrefix string) {
+		line := prefix + strings.Join(n.exportLabel(node), " ")
+		switch node.role {
+		case exportRoleProven:
+			line += " (proven)"
+		case exportRolePath:
+			line += " (recomputed)"
+		case exportRoleProofNode:
+			line += " (proof node)"
+		}
+		fmt.Fprintln(bw, line)
+		for i, child := range node.children {
+			if i == len(node.children)-1 {
+				write(child, childPrefix+"└── ", childPrefix+"    ")
+			} else {
+				write(child, childPrefix+"├── ", childPrefix+"│   ")
+			}
+		}
+	}
+	write(root, "", "")
+	return bw.Flush()
+}
+
+// exportLabel returns the fields of the label of a node: its range, namespace
+// range and digest prefix.
+func (n *NamespacedMerkleTree) exportLabel(node *exportNode) []string {
+	nsSize := int(n.NamespaceSize())
+	digest := node.hash[2*nsSize:]
+	digest = digest[:min(len(digest), exportDigestLen)]
+	return []string{
+		fmt.Sprintf("[%d, %d)", node.r.Start, node.r.End),
+		fmt.Sprintf("%x..%x", node.hash[:nsSize], node.hash[nsSize:2*nsSize]),
+		fmt.Sprintf("%x", digest),
+	}
+}
+
+func dotStyle(role exportRole) string {
+	switch role {
+	case exportRoleProven:
+		return ", style=filled, fillcolor=palegreen"
+	case exportRolePath:
+		return ", style=filled, fillcolor=lightyellow"
+	case exportRoleProofNode:
+		return ", style=filled, fillcolor=lightblue"
+	default:
+		return ""
+	}
+}
+
+// exportTree returns the nodes of the tree, with their roles w.r.t. proof if
+// it is not nil. It returns an error if the proof was not generated by the
+// tree.
+func (n *NamespacedMerkleTree) exportTree(proof *Proof) (*exportNode, error) {
+	rootHash, err := n.Root()
+	if err != nil {
+		return nil, err
+	}
+	if n.Size() == 0 {
+		return &exportNode{hash: rootHash}, nil
+	}
+
+	var proven LeafRange
+	var proofNodes [][]byte
+	if proof != nil && !proof.IsEmptyProof() {
+		if err := n.validateRange(proof.Start(), proof.End()); err != nil {
+			return nil, err
+		}
+		proven = LeafRange{Start: proof.Start(), End: proof.End()}
+		proofNodes = proof.Nodes()
+	}
+
+	var build func(start, end int, parentOverlaps bool) (*exportNode, error)
+	build = func(start, end int, parentOverlaps bool) (*exportNode, error) {
+		hash, err := n.nodeHash(start, end)
+		if err != nil {
+			return nil, err
+		}
+		node := &exportNode{r: LeafRange{Start: start, End: end}, hash: hash}
+		overlaps := start < proven.End && proven.Start < end
+		switch {
+		case overlaps && end-start == 1:
+			node.role = exportRoleProven
+		case overlaps:
+			node.role = exportRolePath
+		case parentOverlaps:
+			if len(proofNodes) == 0 || !bytes.Equal(proofNodes[0], hash) {
+				return nil, fmt.Errorf("proof does not match node [%d, %d) of the tree", start, end)
+			}
+			proofNodes = proofNodes[1:]
+			node.role = exportRoleProofNode
+		}
+		if end-start > 1 {
+			k := getSplitPoint(end - start)
+			left, err := build(start, start+k, overlaps)
+			if err != nil {
+				return nil, err
+			}
+			right, err := build(start+k, end, overlaps)
+			if err != nil {
+				return nil, err
+			}
+			node.children = []*exportNode{left, right}
+		}
+		return node, nil
+	}
+	root, err := build(0, n.Size(), false)
+	if err != nil {
+		return nil, err
+	}
+	if len(proofNodes) != 0 {
+		return nil, fmt.Errorf("proof has %d more nodes than the tree: %w", len(proofNodes), ErrInvalidNodeCount)
+	}
+	return root, nil
+}
@@ -0,0 +1,91 @@
+package nmt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteASCII(t *testing.T) {
+	// the tree of Figure 1 of the specification, see docs/proof-format.md
+	tree := exampleNMT(1, true, 0, 0, 1, 3)
+	proof, err := tree.ProveRange(2, 3)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, tree.WriteASCII(&b, &proof))
+	assert.Equal(t, `[0, 4) 00..03 b1c2cc50 (recomputed)
+├── [0, 2) 00..00 ead8d258 (proof node)
+│   ├── [0, 1) 00..00 5fa0c9c1
+│   └── [1, 2) 00..00 52385a0f
+└── [2, 4) 01..03 52c7c037 (recomputed)
+    ├── [2, 3) 01..01 71ca46ab (proven)
+    └── [3, 4) 03..03 b4a27922 (proof node)
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, tree.WriteASCII(&b, nil))
+	assert.NotContains(t, b.String(), "(")
+	assert.Equal(t, 7, strings.Count(b.String(), "\n"))
+}
+
+func TestWriteDOT(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3, 4, 5)
+	proof, err := tree.ProveRange(1, 3)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, tree.WriteDOT(&b, &proof))
+	dot := b.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph nmt {\n"))
+	assert.True(t, strings.HasSuffix(dot, "}\n"))
+	// 9 nodes, 8 edges
+	assert.Equal(t, 9, strings.Count(dot, "[label="))
+	assert.Equal(t, 8, strings.Count(dot, " -> "))
+	assert.Equal(t, 2, strings.Count(dot, "fillcolor=palegreen"))
+	assert.Equal(t, len(proof.Nodes()), strings.Count(dot, "fillcolor=lightblue"))
+	assert.Contains(t, dot, "\"0-5\" [label=\"[0, 5)\\n01..05\\n")
+	assert.Contains(t, dot, "\"0-4\" -> \"2-4\";")
+	assert.Contains(t, dot, "\"4-5\" [label=\"[4, 5)\\n05..05\\n")
+}
+
+func TestExport_EmptyTree(t *testing.T) {
+	tree := exampleNMT(1, true)
+	var b bytes.Buffer
+	require.NoError(t, tree.WriteASCII(&b, nil))
+	assert.Equal(t, 1, strings.Count(b.String(), "\n"))
+	assert.True(t, strings.HasPrefix(b.String(), "[0, 0) 00..00 "))
+
+	emptyProof := NewEmptyRangeProof(true)
+	b.Reset()
+	require.NoError(t, tree.WriteDOT(&b, &emptyProof))
+	assert.Equal(t, 1, strings.Count(b.String(), "[label="))
+}
+
+func TestExport_InvalidProof(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3, 4)
+	otherProof, err := exampleNMT(1, true, 1, 2, 3, 5).ProveRange(0, 1)
+	require.NoError(t, err)
+	proof, err := tree.ProveRange(0, 1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		proof Proof
+	}{
+		{"proof of another tree", otherProof},
+		{"extra proof node", NewInclusionProof(0, 1, append(proof.Nodes(), proof.Nodes()[0]), true)},
+		{"missing proof node", NewInclusionProof(0, 1, proof.Nodes()[:1], true)},
+		{"out of range", NewInclusionProof(3, 5, nil, true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			assert.Error(t, tree.WriteDOT(&b, &tt.proof))
+			assert.Error(t, tree.WriteASCII(&b, &tt.proof))
+		})
+	}
+}
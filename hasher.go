@@ -43,6 +43,22 @@ type Hasher interface {
 
 var _ Hasher = &NmtHasher{}
 
+// ValidatingHasher is a Hasher that can also validate the format of the
+// leaves and the namespaced hashes it is given. Proofs are verified through
+// this interface, so that the proofs of a tree with a custom hasher, see
+// CustomHasher, can be verified with the same hasher.
+type ValidatingHasher interface {
+	Hasher
+	// ValidateLeaf returns an error if data is not a valid namespaced leaf,
+	// e.g., if it is too short to be prefixed with a namespace ID.
+	ValidateLeaf(data []byte) error
+	// ValidateNodeFormat returns an error if node is not a valid namespaced
+	// hash, i.e., if it is not of the form minNID || maxNID || digest.
+	ValidateNodeFormat(node []byte) error
+}
+
+var _ ValidatingHasher = &NmtHasher{}
+
 // NmtHasher is the default hasher. It follows the description of the original
 // hashing function described in the LazyLedger white paper.
 type NmtHasher struct { //nolint:revive
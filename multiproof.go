@@ -0,0 +1,548 @@
+package nmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/celestiaorg/nmt/namespace"
+	"github.com/celestiaorg/nmt/pb"
+)
+
+// MultiProof represents a Merkle inclusion proof of the leaves at arbitrary
+// indices of an NMT, e.g., a random sample of its leaves. Unlike separate
+// range proofs, it holds every node needed to recompute the root only once.
+type MultiProof struct {
+	// indices of the proven leaves, in ascending order.
+	indices []int
+	// nodes hold the roots of the subtrees that contain none of the proven
+	// leaves but whose parent does, in the order of an in-order traversal of
+	// the tree.
+	nodes [][]byte
+	// isMaxNamespaceIDIgnored is set to true if the tree from which this proof
+	// was generated from is initialized with Options.IgnoreMaxNamespace ==
+	// true, see Proof.
+	isMaxNamespaceIDIgnored bool
+}
+
+// NewMultiProof constructs a proof that proves that the leaves at the given
+// indices are included in an NMT.
+func NewMultiProof(indices []int, proofNodes [][]byte, ignoreMaxNamespace bool) MultiProof {
+	return MultiProof{indices: indices, nodes: proofNodes, isMaxNamespaceIDIgnored: ignoreMaxNamespace}
+}
+
+func (proof MultiProof) MarshalJSON() ([]byte, error) {
+	pbProofObj := MultiProofToProto(proof)
+	return json.Marshal(&pbProofObj)
+}
+
+func (proof *MultiProof) UnmarshalJSON(data []byte) error {
+	var pbProof pb.MultiProof
+	err := json.Unmarshal(data, &pbProof)
+	if err != nil {
+		return err
+	}
+	*proof = ProtoToMultiProof(pbProof)
+	return nil
+}
+
+// Indices returns the indices of the leaves proven by this proof, in
+// ascending order.
+func (proof MultiProof) Indices() []int {
+	return proof.indices
+}
+
+// Nodes return the proof nodes that together with the corresponding leaf values
+// can be used to recompute the root and verify this proof.
+func (proof MultiProof) Nodes() [][]byte {
+	return proof.nodes
+}
+
+// IsMaxNamespaceIDIgnored returns true if the proof has been created under the ignore max namespace logic.
+// see ./docs/nmt-lib.md for more details.
+func (proof MultiProof) IsMaxNamespaceIDIgnored() bool {
+	return proof.isMaxNamespaceIDIgnored
+}
+
+// VerifyLeaves checks that the leaves are included in the tree with the given
+// root at the indices of the proof, in the same order. Unlike
+// Proof.VerifyInclusion, which takes the leaves of a single namespace without
+// their namespace ID, the leaves are namespace-prefixed, as pushed to the
+// tree, since the leaves at arbitrary indices may belong to several
+// namespaces.
+// `h` MUST be the same as the underlying hash function used to generate the
+// proof, and nIDSize the namespace size of the tree.
+func (proof MultiProof) VerifyLeaves(h hash.Hash, nIDSize namespace.IDSize, leaves [][]byte, root []byte) bool {
+	nth := NewNmtHasher(h, nIDSize, proof.isMaxNamespaceIDIgnored)
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hash, err := nth.HashLeaf(leaf)
+		if err != nil {
+			return false
+		}
+		hashes[i] = hash
+	}
+	res, err := proof.VerifyLeafHashes(nth, hashes, root)
+	if err != nil {
+		return false
+	}
+	return res
+}
+
+// VerifyLeafHashes checks whether the proof is a valid Merkle proof of the
+// leaf hashes at the indices of the proof, in the same order. It returns true
+// or false accordingly. If there is an issue during the proof verification,
+// e.g., a node does not conform to the namespace hash format, then a proper
+// error is returned to indicate the root cause of the issue.
+func (proof MultiProof) VerifyLeafHashes(nth ValidatingHasher, leafHashes [][]byte, root []byte) (bool, error) {
+	if len(proof.indices) == 0 {
+		return false, fmt.Errorf("%w: the proof has no indices", ErrInvalidRange)
+	}
+	if len(leafHashes) != len(proof.indices) {
+		return false, fmt.Errorf("supplied leafHashes size %d, expected size %d: %w", len(leafHashes), len(proof.indices), ErrWrongLeafHashesSize)
+	}
+	var ranges []LeafRange
+	for i, index := range proof.indices {
+		if index < 0 || (i > 0 && index <= proof.indices[i-1]) {
+			return false, fmt.Errorf("%w: proof indices must be non-negative and strictly increasing", ErrInvalidRange)
+		}
+		if len(ranges) > 0 && ranges[len(ranges)-1].End == index {
+			ranges[len(ranges)-1].End++
+		} else {
+			ranges = append(ranges, LeafRange{Start: index, End: index + 1})
+		}
+	}
+
+	// check that the root, the proof nodes and the leaf hashes are valid
+	// w.r.t the NMT hasher
+	if err := nth.ValidateNodeFormat(root); err != nil {
+		return false, fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+	}
+	for _, node := range proof.nodes {
+		if err := nth.ValidateNodeFormat(node); err != nil {
+			return false, fmt.Errorf("%w: proof nodes do not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+		}
+	}
+	for _, leafHash := range leafHashes {
+		if err := nth.ValidateNodeFormat(leafHash); err != nil {
+			return false, fmt.Errorf("%w: leaf hash does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+		}
+	}
+
+	rootHash, _, err := computeRangesRoot(nth, ranges, leafHashes, proof.nodes, 0, nil)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(rootHash, root), nil
+}
+
+// MultiProofToProto returns the proto representation of a multiproof.
+func MultiProofToProto(proof MultiProof) pb.MultiProof {
+	indices := make([]int64, len(proof.indices))
+	for i, index := range proof.indices {
+		indices[i] = int64(index)
+	}
+	return pb.MultiProof{
+		Indices:               indices,
+		Nodes:                 proof.nodes,
+		IsMaxNamespaceIgnored: proof.isMaxNamespaceIDIgnored,
+	}
+}
+
+// ProtoToMultiProof creates a multiproof from its proto representation.
+func ProtoToMultiProof(protoProof pb.MultiProof) MultiProof {
+	var indices []int
+	if len(protoProof.Indices) > 0 {
+		indices = make([]int, len(protoProof.Indices))
+		for i, index := range protoProof.Indices {
+			indices[i] = int(index)
+		}
+	}
+	return NewMultiProof(indices, protoProof.Nodes, protoProof.IsMaxNamespaceIgnored)
+}
+
+// MultiNamespaceProof represents a proof of several namespace.IDs in an NMT.
+// For each namespace, it is equivalent to the Proof that ProveNamespace would
+// return, but the nodes shared by the proofs of the different namespaces are
+// only included once.
+type MultiNamespaceProof struct {
+	// ranges holds, for each proven namespace in ascending order, the range of
+	// the leaves matching the namespace, the range of the leaf proving its
+	// absence, or an empty range if the namespace is outside the namespace
+	// range of the tree.
+	ranges []LeafRange
+	// leafHashes holds, for each proven namespace, the hash of the leaf
+	// proving its absence, or nil if the namespace has leaves in the tree or
+	// is outside its namespace range.
+	leafHashes [][]byte
+	// nodes hold the tree nodes necessary for the Merkle range proof of the
+	// union of the ranges, in the order of an in-order traversal of the tree.
+	nodes [][]byte
+	// isMaxNamespaceIDIgnored is set to true if the tree from which this proof
+	// was generated from is initialized with Options.IgnoreMaxNamespace ==
+	// true, see Proof.
+	isMaxNamespaceIDIgnored bool
+}
+
+// Ranges returns, for each proven namespace, the range of the leaves matching
+// the namespace, or of the leaf proving its absence. The range is empty if the
+// namespace is outside the namespace range of the tree.
+func (proof MultiNamespaceProof) Ranges() []LeafRange {
+	return proof.ranges
+}
+
+// LeafHashes returns, for each proven namespace, the hash of the leaf proving
+// its absence, or nil if the namespace has leaves in the NMT or if it is
+// outside the namespace range of the tree.
+func (proof MultiNamespaceProof) LeafHashes() [][]byte {
+	return proof.leafHashes
+}
+
+// Nodes return the proof nodes that together with the corresponding leaf values
+// can be used to recompute the root and verify this proof.
+func (proof MultiNamespaceProof) Nodes() [][]byte {
+	return proof.nodes
+}
+
+// IsMaxNamespaceIDIgnored returns true if the proof has been created under the ignore max namespace logic.
+// see ./docs/nmt-lib.md for more details.
+func (proof MultiNamespaceProof) IsMaxNamespaceIDIgnored() bool {
+	return proof.isMaxNamespaceIDIgnored
+}
+
+// VerifyNamespaces verifies several whole namespaces at once, i.e., for each
+// namespace nIDs[i], it verifies that leaves[i] are all the leaves of that
+// namespace in the tree, like VerifyNamespace. For a namespace that is absent
+// from the tree, leaves[i] must be empty.
+//
+// `h` MUST be the same as the underlying hash function used to generate the
+// proof. `nIDs` MUST be the namespace IDs for which the proof was generated,
+// in the same ascending order. `root` is the root of the NMT against which the
+// proof is verified.
+func (proof MultiNamespaceProof) VerifyNamespaces(h hash.Hash, nIDs []namespace.ID, leaves [][][]byte, root []byte) bool {
+	return proof.VerifyNamespacesErr(h, nIDs, leaves, root) == nil
+}
+
+// VerifyNamespacesErr is like VerifyNamespaces, but returns an error
+// describing why the verification failed instead of false, and nil if the
+// proof is valid. The error wraps the same sentinel errors as
+// Proof.VerifyNamespaceErr, where ErrNamespaceMismatch also reports namespace
+// IDs that are not of the same size or not in ascending order.
+func (proof MultiNamespaceProof) VerifyNamespacesErr(h hash.Hash, nIDs []namespace.ID, leaves [][][]byte, root []byte) error {
+	if len(nIDs) != len(proof.ranges) || len(nIDs) != len(proof.leafHashes) {
+		return fmt.Errorf("%w: proof of %d namespaces, got %d namespace IDs", ErrInvalidRange, len(proof.ranges), len(nIDs))
+	}
+	if len(nIDs) != len(leaves) {
+		return fmt.Errorf("%w: got the leaves of %d namespaces for %d namespace IDs", ErrWrongLeafHashesSize, len(leaves), len(nIDs))
+	}
+	if len(nIDs) == 0 {
+		if len(proof.nodes) != 0 {
+			return fmt.Errorf("%w: got %d proof nodes for no namespace", ErrInvalidNodeCount, len(proof.nodes))
+		}
+		return nil
+	}
+	nIDLen := nIDs[0].Size()
+	nth := NewNmtHasher(h, nIDLen, proof.isMaxNamespaceIDIgnored)
+
+	// check that the root and the proof nodes are valid w.r.t the NMT hasher
+	if err := nth.ValidateNodeFormat(root); err != nil {
+		return fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+	}
+	for _, node := range proof.nodes {
+		if err := nth.ValidateNodeFormat(node); err != nil {
+			return fmt.Errorf("%w: proof nodes do not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+		}
+	}
+	for i, nID := range nIDs {
+		if nID.Size() != nIDLen {
+			return fmt.Errorf("%w: namespace ID %x is not of size %d", ErrNamespaceMismatch, nID, nIDLen)
+		}
+		if i > 0 && !nIDs[i-1].Less(nID) {
+			return fmt.Errorf("%w: namespace ID %x is not larger than %x", ErrNamespaceMismatch, nID, nIDs[i-1])
+		}
+	}
+	rootMin := namespace.ID(MinNamespace(root, nIDLen))
+	rootMax := namespace.ID(MaxNamespace(root, nIDLen))
+
+	// collect the hashes of the leaves in the ranges of all the namespaces
+	hashes := make(map[int][]byte)
+	for i, nID := range nIDs {
+		r := proof.ranges[i]
+		if r.Start < 0 || r.Start > r.End {
+			return fmt.Errorf("%w: [%d, %d) for namespace %x", ErrInvalidRange, r.Start, r.End, nID)
+		}
+		// the namespace is outside the range of the tree, like for an empty
+		// Proof
+		if r.Start == r.End {
+			if len(proof.leafHashes[i]) != 0 {
+				return fmt.Errorf("%w: empty proof range with a leaf hash for namespace %x", ErrInvalidRange, nID)
+			}
+			if len(leaves[i]) != 0 {
+				return fmt.Errorf("supplied %d leaves for the empty proof range of namespace %x: %w", len(leaves[i]), nID, ErrWrongLeafHashesSize)
+			}
+			if !nID.Less(rootMin) && !rootMax.Less(nID) && !bytes.Equal(root, nth.EmptyRoot()) {
+				return fmt.Errorf("%w: empty proof for namespace %x within the range of the root", ErrFailedCompletenessCheck, nID)
+			}
+			continue
+		}
+
+		var leafHashes [][]byte
+		if leafHash := proof.leafHashes[i]; len(leafHash) > 0 {
+			// the namespace is absent, and the leaf hash must be the one of
+			// the next leaf
+			if r.End-r.Start != 1 {
+				return fmt.Errorf("%w: absence proof of [%d, %d) for namespace %x", ErrInvalidRange, r.Start, r.End, nID)
+			}
+			if len(leaves[i]) != 0 {
+				return fmt.Errorf("supplied %d leaves for the absent namespace %x: %w", len(leaves[i]), nID, ErrWrongLeafHashesSize)
+			}
+			if err := nth.ValidateNodeFormat(leafHash); err != nil {
+				return fmt.Errorf("%w: leaf hash does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+			}
+			if !nID.Less(MinNamespace(leafHash, nIDLen)) {
+				return fmt.Errorf("%w: leaf hash %x does not prove the absence of namespace %x", ErrFailedCompletenessCheck, leafHash, nID)
+			}
+			leafHashes = [][]byte{leafHash}
+		} else {
+			if len(leaves[i]) != r.End-r.Start {
+				return fmt.Errorf("supplied %d leaves for the range [%d, %d) of namespace %x: %w", len(leaves[i]), r.Start, r.End, nID, ErrWrongLeafHashesSize)
+			}
+			var err error
+			leafHashes, err = ComputeAndValidateLeafHashes(nth, nID, leaves[i])
+			if err != nil {
+				return err
+			}
+		}
+		for j, leafHash := range leafHashes {
+			// the same leaf may prove the absence of several namespaces or
+			// both the presence and the absence of namespaces
+			if prev, ok := hashes[r.Start+j]; ok && !bytes.Equal(prev, leafHash) {
+				return fmt.Errorf("%w: leaf %d is proven with different hashes", ErrRootMismatch, r.Start+j)
+			}
+			hashes[r.Start+j] = leafHash
+		}
+	}
+
+	// all the namespaces are outside the range of the tree
+	if len(hashes) == 0 {
+		if len(proof.nodes) != 0 {
+			return fmt.Errorf("%w: got %d proof nodes for namespaces outside the range of the tree", ErrInvalidNodeCount, len(proof.nodes))
+		}
+		return nil
+	}
+
+	indices := make([]int, 0, len(hashes))
+	for index := range hashes {
+		indices = append(indices, index)
+	}
+	slices.Sort(indices)
+	var ranges []LeafRange
+	leafHashes := make([][]byte, 0, len(indices))
+	for _, index := range indices {
+		if len(ranges) > 0 && ranges[len(ranges)-1].End == index {
+			ranges[len(ranges)-1].End++
+		} else {
+			ranges = append(ranges, LeafRange{Start: index, End: index + 1})
+		}
+		leafHashes = append(leafHashes, hashes[index])
+	}
+
+	rootHash, nodeStarts, err := computeRangesRoot(nth, ranges, leafHashes, proof.nodes, 0, nil)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(rootHash, root) {
+		return ErrRootMismatch
+	}
+
+	// check the completeness of every namespace: the nodes and leaves on the
+	// left of its range must only have smaller namespace IDs, and those on
+	// its right only larger namespace IDs
+	for i, nID := range nIDs {
+		r := proof.ranges[i]
+		if r.Start == r.End {
+			continue
+		}
+		for j, node := range proof.nodes {
+			if !isOutsideNamespace(nth, nID, node, nodeStarts[j] < r.Start) {
+				return fmt.Errorf("%w: proof node %d may hold leaves of namespace %x", ErrFailedCompletenessCheck, j, nID)
+			}
+		}
+		for j, index := range indices {
+			if index >= r.Start && index < r.End {
+				continue
+			}
+			if !isOutsideNamespace(nth, nID, leafHashes[j], index < r.Start) {
+				return fmt.Errorf("%w: leaf %d may belong to namespace %x", ErrFailedCompletenessCheck, index, nID)
+			}
+		}
+	}
+	return nil
+}
+
+// isOutsideNamespace reports whether all the namespace IDs covered by the
+// namespaced hash of a node are smaller than nID if the node is on the left of
+// the leaves of nID, or larger than nID otherwise.
+func isOutsideNamespace(nth ValidatingHasher, nID namespace.ID, node []byte, isLeft bool) bool {
+	if isLeft {
+		return namespace.ID(MaxNamespace(node, nth.NamespaceSize())).Less(nID)
+	}
+	return nID.Less(MinNamespace(node, nth.NamespaceSize()))
+}
+
+// computeRangesRoot computes the root of a tree from the hashes of the leaves
+// in the given ranges, which must be sorted and non-overlapping, and the proof
+// nodes of these ranges. It also returns, for every proof node, the index of
+// the first leaf it covers, or math.MaxInt for the nodes that lie beyond the
+// estimated size of the tree. If treeSize is not 0, the shape of the tree is
+// that of a tree of treeSize leaves instead of being estimated from the
+// ranges. If trace is not nil, it is called for every leaf hash and proof node
+// consumed and for every node hashed.
+func computeRangesRoot(h Hasher, ranges []LeafRange, leafHashes, nodes [][]byte, treeSize int, trace func(TraceStep)) ([]byte, []int, error) {
+	nodeStarts := make([]int, 0, len(nodes))
+	popNode := func(start, end int) []byte {
+		if len(nodes) == 0 {
+			return nil
+		}
+		if trace != nil {
+			trace(TraceStep{Kind: TraceProofNode, Range: LeafRange{Start: start, End: end}, Index: len(nodeStarts), Hash: nodes[0]})
+		}
+		nodeStarts = append(nodeStarts, start)
+		return popIfNonEmpty(&nodes)
+	}
+	leafIndex := 0
+	popLeaf := func(start int) []byte {
+		if len(leafHashes) == 0 {
+			return nil
+		}
+		if trace != nil {
+			trace(TraceStep{Kind: TraceLeaf, Range: LeafRange{Start: start, End: start + 1}, Index: leafIndex, Hash: leafHashes[0]})
+		}
+		leafIndex++
+		return popIfNonEmpty(&leafHashes)
+	}
+	hashNode := func(r LeafRange, left, right []byte) ([]byte, error) {
+		hash, err := h.HashNode(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash node: %w", err)
+		}
+		if trace != nil {
+			trace(TraceStep{Kind: TraceHashNode, Range: r, Index: -1, Left: left, Right: right, Hash: hash})
+		}
+		return hash, nil
+	}
+
+	var computeRoot func(start, end int) ([]byte, error)
+	// computeRoot can return error iff the HashNode function fails while calculating the root
+	computeRoot = func(start, end int) ([]byte, error) {
+		overlaps, _ := rangesOverlap(ranges, start, end)
+
+		// reached a leaf
+		if end-start == 1 {
+			// if the leaf index falls within one of the ranges, pop and
+			// return a leaf
+			if overlaps {
+				// advance leafHashes
+				return popLeaf(start), nil
+			}
+
+			// if the leaf index is outside the ranges, pop and return a proof
+			// node (which in this case is a leaf) if present, else return nil
+			// because leaf doesn't exist
+			return popNode(start, end), nil
+		}
+
+		// if current range does not overlap with the ranges, pop and return a
+		// proof node if present, else return nil because subtree doesn't
+		// exist
+		if !overlaps {
+			return popNode(start, end), nil
+		}
+
+		// Recursively get left and right subtree
+		k := getSplitPoint(end - start)
+		left, err := computeRoot(start, start+k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start, start+k, err)
+		}
+		right, err := computeRoot(start+k, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start+k, end, err)
+		}
+
+		// only right leaf/subtree can be non-existent
+		if right == nil {
+			return left, nil
+		}
+		if left == nil {
+			return nil, fmt.Errorf("%w: missing node for subtree [%d, %d)", ErrInvalidNodeCount, start, start+k)
+		}
+		return hashNode(LeafRange{Start: start, End: end}, left, right)
+	}
+
+	// estimate the leaf size of the subtree containing the ranges
+	proofRangeSubtreeEstimate := max(getSplitPoint(ranges[len(ranges)-1].End)*2, 1)
+	if treeSize > 0 {
+		proofRangeSubtreeEstimate = treeSize
+	}
+	rootHash, err := computeRoot(0, proofRangeSubtreeEstimate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute root [%d, %d): %w", 0, proofRangeSubtreeEstimate, err)
+	}
+	if treeSize > 0 && len(nodes) > 0 {
+		return nil, nil, fmt.Errorf("%w: %d unused proof nodes for a tree of size %d", ErrInvalidNodeCount, len(nodes), treeSize)
+	}
+	// the remaining nodes are the roots of the subtrees on the right of the
+	// estimated subtree, whose sizes cannot be derived from the proof
+	for covered := proofRangeSubtreeEstimate; len(nodes) > 0; covered *= 2 {
+		node := popNode(covered, -1)
+		nodeStarts[len(nodeStarts)-1] = math.MaxInt
+		rootHash, err = hashNode(LeafRange{Start: 0, End: -1}, rootHash, node)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return rootHash, nodeStarts, nil
+}
+
+// rangesOverlap reports whether the leaf range [start, end) overlaps any of
+// the given ranges, which must be sorted and non-overlapping, and whether it
+// is fully covered by one of them.
+func rangesOverlap(ranges []LeafRange, start, end int) (overlaps, covered bool) {
+	// the first range ending after start
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].End > start
+	})
+	if i == len(ranges) || ranges[i].Start >= end {
+		return false, false
+	}
+	return true, ranges[i].Start <= start && end <= ranges[i].End
+}
+
+// mergeRanges returns the union of the given leaf ranges as a sorted list of
+// non-overlapping and non-adjacent ranges. Empty ranges are ignored.
+func mergeRanges(ranges []LeafRange) []LeafRange {
+	merged := make([]LeafRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Start < r.End {
+			merged = append(merged, r)
+		}
+	}
+	slices.SortFunc(merged, func(a, b LeafRange) int {
+		return a.Start - b.Start
+	})
+	i := 0
+	for _, r := range merged {
+		if i > 0 && r.Start <= merged[i-1].End {
+			merged[i-1].End = max(merged[i-1].End, r.End)
+			continue
+		}
+		merged[i] = r
+		i++
+	}
+	return merged[:i]
+}
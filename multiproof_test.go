@@ -0,0 +1,314 @@
+package nmt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+	"github.com/celestiaorg/nmt/pb"
+)
+
+func TestProveNamespaces(t *testing.T) {
+	const nidSize = 1
+	for _, ignoreMaxNamespace := range []bool{true, false} {
+		tree := exampleNMT(nidSize, ignoreMaxNamespace, 1, 1, 2, 4, 4, 4, 6, 8, 8, 255)
+		root, err := tree.Root()
+		require.NoError(t, err)
+
+		// all the subsets of the namespaces from 0 to 9, plus the maximum one
+		candidates := []namespace.ID{{0}, {1}, {2}, {3}, {4}, {5}, {6}, {7}, {8}, {9}, {255}}
+		for subset := 1; subset < 1<<len(candidates); subset++ {
+			var nIDs []namespace.ID
+			for i, nID := range candidates {
+				if subset&(1<<i) != 0 {
+					nIDs = append(nIDs, nID)
+				}
+			}
+			name := fmt.Sprintf("ignoreMaxNamespace=%v/namespaces=%v", ignoreMaxNamespace, nIDs)
+			proof, err := tree.ProveNamespaces(nIDs)
+			require.NoError(t, err, name)
+			require.Len(t, proof.Ranges(), len(nIDs))
+			assert.Equal(t, ignoreMaxNamespace, proof.IsMaxNamespaceIDIgnored())
+
+			// every namespace has the same range as in its own proof, and the
+			// nodes shared by the proofs are deduplicated
+			leaves := make([][][]byte, len(nIDs))
+			individualNodes := 0
+			for i, nID := range nIDs {
+				single, err := tree.ProveNamespace(nID)
+				require.NoError(t, err)
+				assert.Equal(t, LeafRange{Start: single.Start(), End: single.End()}, proof.Ranges()[i], name)
+				assert.Equal(t, single.LeafHash(), proof.LeafHashes()[i], name)
+				individualNodes += len(single.Nodes())
+				// the leaves of the maximum namespace are not proven when it
+				// is ignored
+				if !single.IsEmptyProof() {
+					leaves[i] = tree.Get(nID)
+				}
+			}
+			assert.LessOrEqual(t, len(proof.Nodes()), individualNodes, name)
+
+			assert.True(t, proof.VerifyNamespaces(sha256.New(), nIDs, leaves, root), name)
+		}
+	}
+}
+
+func TestProveNamespaces_SharedNodes(t *testing.T) {
+	tree := exampleNMT(1, true, 0, 1, 2, 3, 4, 5, 6, 7)
+	nIDs := []namespace.ID{{2}, {3}}
+	proof, err := tree.ProveNamespaces(nIDs)
+	require.NoError(t, err)
+	// the proof of the subtree [2, 4) only needs the nodes [0, 2) and [4, 8),
+	// while the proofs of each namespace need three nodes each
+	assert.Len(t, proof.Nodes(), 2)
+}
+
+func TestProveNamespaces_EmptyTree(t *testing.T) {
+	tree := exampleNMT(1, true)
+	root, err := tree.Root()
+	require.NoError(t, err)
+
+	nIDs := []namespace.ID{{0}, {1}}
+	proof, err := tree.ProveNamespaces(nIDs)
+	require.NoError(t, err)
+	assert.Equal(t, []LeafRange{{}, {}}, proof.Ranges())
+	assert.Empty(t, proof.Nodes())
+	assert.True(t, proof.VerifyNamespaces(sha256.New(), nIDs, make([][][]byte, len(nIDs)), root))
+}
+
+func TestProveNamespaces_Errors(t *testing.T) {
+	tree := exampleNMT(2, true, 1, 2, 3)
+	tests := []struct {
+		name string
+		nIDs []namespace.ID
+	}{
+		{"unordered namespaces", []namespace.ID{{2, 2}, {1, 1}}},
+		{"duplicated namespaces", []namespace.ID{{1, 1}, {1, 1}}},
+		{"invalid namespace size", []namespace.ID{{1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tree.ProveNamespaces(tt.nIDs)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVerifyNamespacesErr(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 1, 2, 4, 4, 6, 8)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	// 0 is out of range, 3 and 5 are absent and the others are present
+	nIDs := []namespace.ID{{0}, {1}, {3}, {4}, {5}, {8}}
+	proof, err := tree.ProveNamespaces(nIDs)
+	require.NoError(t, err)
+	leaves := make([][][]byte, len(nIDs))
+	for i, nID := range nIDs {
+		leaves[i] = tree.Get(nID)
+	}
+	require.True(t, proof.VerifyNamespaces(sha256.New(), nIDs, leaves, root))
+
+	// copy returns a deep copy of the valid proof and leaves
+	copyProof := func() (MultiNamespaceProof, [][][]byte) {
+		p := proof
+		p.ranges = append([]LeafRange(nil), proof.ranges...)
+		p.leafHashes = append([][]byte(nil), proof.leafHashes...)
+		p.nodes = append([][]byte(nil), proof.nodes...)
+		l := make([][][]byte, len(leaves))
+		for i := range leaves {
+			l[i] = append([][]byte(nil), leaves[i]...)
+		}
+		return p, l
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte)
+		wantErr error
+	}{
+		{"missing leaf of a namespace", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			p.ranges[1].End--
+			l[1] = l[1][:1]
+			return nIDs, l, root
+		}, ErrInvalidNodeCount},
+		{"leaf of another namespace", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			l[1][0] = l[3][0]
+			return nIDs, l, root
+		}, ErrNamespaceMismatch},
+		{"absent namespace claimed out of range", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			p.ranges[2] = LeafRange{}
+			p.leafHashes[2] = nil
+			return nIDs, l, root
+		}, ErrFailedCompletenessCheck},
+		{"present namespace claimed absent", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			p.ranges[3] = LeafRange{Start: 5, End: 6}
+			p.leafHashes[3] = tree.leafHashes[5]
+			l[3] = nil
+			return nIDs, l, root
+		}, ErrInvalidNodeCount},
+		{"wrong absence leaf", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			p.leafHashes[4] = tree.leafHashes[4]
+			return nIDs, l, root
+		}, ErrFailedCompletenessCheck},
+		{"modified node", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			p.nodes[0] = tree.leafHashes[0]
+			return nIDs, l, root
+		}, ErrRootMismatch},
+		{"missing node", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			p.nodes = p.nodes[:len(p.nodes)-1]
+			return nIDs, l, root
+		}, ErrInvalidNodeCount},
+		{"unordered namespaces", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			unordered := append([]namespace.ID(nil), nIDs...)
+			unordered[0], unordered[1] = unordered[1], unordered[0]
+			return unordered, l, root
+		}, ErrNamespaceMismatch},
+		{"namespace of another size", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			resized := append([]namespace.ID(nil), nIDs...)
+			resized[2] = namespace.ID{3, 0}
+			return resized, l, root
+		}, ErrNamespaceMismatch},
+		{"leaves of an absent namespace", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			l[2] = [][]byte{tree.leaves[2]}
+			return nIDs, l, root
+		}, ErrWrongLeafHashesSize},
+		{"fewer namespaces", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			return nIDs[1:], l[1:], root
+		}, ErrInvalidRange},
+		{"wrong root", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			otherRoot, err := exampleNMT(1, true, 1, 1, 2, 4, 4, 6, 9).Root()
+			require.NoError(t, err)
+			return nIDs, l, otherRoot
+		}, ErrRootMismatch},
+		{"short root", func(p *MultiNamespaceProof, l [][][]byte) ([]namespace.ID, [][][]byte, []byte) {
+			return nIDs, l, root[:1]
+		}, ErrInvalidNodeFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, l := copyProof()
+			nIDs, l, root := tt.modify(&p, l)
+			assert.False(t, p.VerifyNamespaces(sha256.New(), nIDs, l, root))
+			err := p.VerifyNamespacesErr(sha256.New(), nIDs, l, root)
+			assert.ErrorIs(t, err, tt.wantErr, "%v", err)
+		})
+	}
+}
+
+func TestProveIndices(t *testing.T) {
+	const nidSize = 2
+	for size := 1; size <= 11; size++ {
+		data, err := generateRandNamespacedRawData(size, nidSize, 8)
+		require.NoError(t, err)
+		tree := New(sha256.New(), NamespaceIDSize(nidSize))
+		for _, d := range data {
+			require.NoError(t, tree.Push(d))
+		}
+		root, err := tree.Root()
+		require.NoError(t, err)
+
+		// all the subsets of the leaves
+		for subset := 1; subset < 1<<size; subset++ {
+			var indices []int
+			var leaves [][]byte
+			for i := 0; i < size; i++ {
+				if subset&(1<<i) != 0 {
+					indices = append(indices, i)
+					leaves = append(leaves, data[i])
+				}
+			}
+			name := fmt.Sprintf("size=%d/indices=%v", size, indices)
+			proof, err := tree.ProveIndices(indices)
+			require.NoError(t, err, name)
+			assert.Equal(t, indices, proof.Indices())
+			assert.Equal(t, minimalProofNodes(indices, 0, size), len(proof.Nodes()), name)
+			assert.True(t, proof.VerifyLeaves(sha256.New(), nidSize, leaves, root), name)
+		}
+	}
+}
+
+// minimalProofNodes returns the number of nodes of the subtree [start, end)
+// that contain none of the indices but whose parent does.
+func minimalProofNodes(indices []int, start, end int) int {
+	included := 0
+	for _, index := range indices {
+		if index >= start && index < end {
+			included++
+		}
+	}
+	switch included {
+	case 0:
+		return 1
+	case end - start:
+		return 0
+	}
+	k := getSplitPoint(end - start)
+	return minimalProofNodes(indices, start, start+k) + minimalProofNodes(indices, start+k, end)
+}
+
+func TestProveIndices_Errors(t *testing.T) {
+	tree := exampleNMT(1, true, 0, 1, 2, 3, 4)
+	for _, indices := range [][]int{nil, {-1}, {5}, {1, 1}, {3, 2}} {
+		t.Run(fmt.Sprintf("indices=%v", indices), func(t *testing.T) {
+			_, err := tree.ProveIndices(indices)
+			assert.ErrorIs(t, err, ErrInvalidRange)
+		})
+	}
+}
+
+func TestMultiProof_VerifyLeaves_False(t *testing.T) {
+	tree := exampleNMT(1, true, 0, 1, 2, 3, 4, 5, 6)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	indices := []int{1, 2, 5}
+	proof, err := tree.ProveIndices(indices)
+	require.NoError(t, err)
+	leaves := [][]byte{tree.leaves[1], tree.leaves[2], tree.leaves[5]}
+	require.True(t, proof.VerifyLeaves(sha256.New(), 1, leaves, root))
+
+	tests := []struct {
+		name   string
+		proof  MultiProof
+		leaves [][]byte
+	}{
+		{"wrong leaf", proof, [][]byte{tree.leaves[1], tree.leaves[3], tree.leaves[5]}},
+		{"unordered leaves", proof, [][]byte{tree.leaves[2], tree.leaves[1], tree.leaves[5]}},
+		{"missing leaf", proof, leaves[:2]},
+		{"wrong indices", NewMultiProof([]int{1, 2, 6}, proof.Nodes(), true), leaves},
+		{"unordered indices", NewMultiProof([]int{2, 1, 5}, proof.Nodes(), true), leaves},
+		{"no indices", NewMultiProof(nil, proof.Nodes(), true), nil},
+		{"missing node", NewMultiProof(indices, proof.Nodes()[1:], true), leaves},
+		{"extra node", NewMultiProof(indices, append(proof.Nodes(), tree.leafHashes[6]), true), leaves},
+		{"invalid node", NewMultiProof(indices, [][]byte{{0}}, true), leaves},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.False(t, tt.proof.VerifyLeaves(sha256.New(), 1, tt.leaves, root))
+		})
+	}
+}
+
+func TestMultiProof_Encoding(t *testing.T) {
+	tree := exampleNMT(1, true, 0, 1, 2, 3, 4, 5, 6)
+	proof, err := tree.ProveIndices([]int{0, 3, 4, 6})
+	require.NoError(t, err)
+
+	// JSON
+	jsonProof, err := proof.MarshalJSON()
+	require.NoError(t, err)
+	var fromJSON MultiProof
+	require.NoError(t, fromJSON.UnmarshalJSON(jsonProof))
+	assert.Equal(t, proof, fromJSON)
+
+	// protobuf
+	pbProof := MultiProofToProto(proof)
+	bin, err := pbProof.Marshal()
+	require.NoError(t, err)
+	var decoded pb.MultiProof
+	require.NoError(t, decoded.Unmarshal(bin))
+	assert.Equal(t, proof, ProtoToMultiProof(decoded))
+}
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"hash"
 	"math/bits"
-	"unsafe"
+	"slices"
+	"sort"
+	"sync"
 
 	"github.com/celestiaorg/nmt/namespace"
 )
@@ -21,6 +23,10 @@ const (
 var (
 	ErrInvalidRange     = errors.New("invalid proof range")
 	ErrInvalidPushOrder = errors.New("pushed data has to be lexicographically ordered by namespace IDs")
+	// ErrLeafDataUnavailable indicates that the tree only holds the hashes of
+	// its leaves, e.g., because it was created with NewFromLeafHashes, and not
+	// the leaves themselves.
+	ErrLeafDataUnavailable = errors.New("leaf data is not available")
 )
 
 type NodeVisitorFn = func(hash []byte, children ...[]byte)
@@ -42,6 +48,18 @@ type Options struct {
 	// ReuseBuffers determines whether memory buffers should be reused to optimize performance and reduce allocations.
 	ReuseBuffers bool
 	Hasher       Hasher
+	// NodeStore is the store in which the nodes of the tree are kept. If not
+	// set, the nodes are kept in memory.
+	NodeStore NodeStore
+	// ParallelWorkers is the maximum number of goroutines used to compute the
+	// root of the tree. Values smaller than 2 disable parallel hashing.
+	ParallelWorkers int
+	// NewBaseHash creates instances of the base hash function of the tree, one
+	// for each of the ParallelWorkers.
+	NewBaseHash func() hash.Hash
+	// ShortAbsenceProofs determines whether ProveNamespace proves the absence
+	// of a namespace with the highest possible node instead of a leaf.
+	ShortAbsenceProofs bool
 }
 
 type Option func(*Options)
@@ -79,6 +97,10 @@ func IgnoreMaxNamespace(ignore bool) Option {
 	}
 }
 
+// NodeVisitor sets a function that is called on every node hashed when the
+// root of the tree is computed, along with its children, or with its data for
+// a leaf. The inner nodes reused from the node store, i.e., those whose leaves
+// did not change since they were last computed, are not visited again.
 func NodeVisitor(nodeVisitorFn NodeVisitorFn) Option {
 	return func(opts *Options) {
 		opts.NodeVisitor = nodeVisitorFn
@@ -92,8 +114,45 @@ func CustomHasher(h Hasher) Option {
 	}
 }
 
+// CustomNodeStore replaces the default in-memory node store. The tree writes
+// its nodes through the store whenever its root is computed, and reads them
+// from the store to generate proofs.
+func CustomNodeStore(s NodeStore) Option {
+	return func(o *Options) {
+		o.NodeStore = s
+	}
+}
+
+// ParallelHashing computes the root of the tree using up to workers
+// goroutines: above a size threshold, the left and right subtrees of a node
+// are hashed concurrently. Since hashers are stateful, each worker gets its
+// own instance of the default hasher, built with a fresh base hash function
+// returned by newHash, which must return instances of the same hash function
+// as the one passed to New. The resulting root is identical to the one
+// computed sequentially.
+//
+// Parallel hashing is not available in combination with CustomHasher, in
+// which case the root is computed sequentially.
+//
+// When a NodeVisitor is set, its calls are serialized, and every node is still
+// visited after its children. However, nodes of disjoint subtrees may be
+// visited in any relative order, instead of the post-order of sequential
+// hashing.
+func ParallelHashing(workers int, newHash func() hash.Hash) Option {
+	if workers < 1 {
+		panic("Got invalid number of workers. Expected int greater or equal to 1.")
+	}
+	if newHash == nil {
+		panic("Got nil hash function constructor.")
+	}
+	return func(o *Options) {
+		o.ParallelWorkers = workers
+		o.NewBaseHash = newHash
+	}
+}
+
 // ReuseBuffers option will use default's hasher buffer reuse capabilities
-// and reuse part of leaves' buffers to check if the namespace exists in a map.
+// and keep the buffers of the namespace index of the tree across Reset calls.
 // Bear in mind that when we want to reuse the NMT for the next batch of data (e.g. new square row),
 // we need to call `Reset` on the tree.
 func ReuseBuffers(reuse bool) Option {
@@ -102,14 +161,69 @@ func ReuseBuffers(reuse bool) Option {
 	}
 }
 
+// ShortAbsenceProofs makes ProveNamespace return the shortest possible
+// absence proofs: instead of the leaf following the position of the absent
+// namespace, the proof holds the highest node of the tree whose leftmost leaf
+// is that leaf, which saves a proof node per level of the tree above it, e.g.,
+// in sparse rows. Its range is the position of the node among the nodes of
+// its level, i.e., [i, i+1) for the i-th node of the level.
+//
+// Such a proof is guaranteed to verify under VerifyNamespace, but it does not
+// hold the size of the tree, see Proof.TreeSize, since the shape of the tree
+// is checked leaf by leaf. Absence proofs of a leaf that is not the leftmost
+// leaf of any inner node, e.g., of an odd index, are the same as without this
+// option. The proofs returned by ProveNamespaces are not affected.
+func ShortAbsenceProofs(short bool) Option {
+	return func(o *Options) {
+		o.ShortAbsenceProofs = short
+	}
+}
+
 type NamespacedMerkleTree struct {
 	// reuseBuffers determines whether buffers should be reused to optimize memory usage and reduce allocations.
 	reuseBuffers bool
-	treeHasher   Hasher
-	visit        NodeVisitorFn
-
-	// just cache stuff until we pass in a store and keep all nodes in there
-	// currently, only leaves and leafHashes are stored:
+	// shortAbsenceProofs determines whether ProveNamespace returns the
+	// shortest possible absence proofs, see ShortAbsenceProofs.
+	shortAbsenceProofs bool
+	treeHasher         Hasher
+	visit              NodeVisitorFn
+	// visitMtx serializes the calls to visit when hashing in parallel.
+	visitMtx sync.Mutex
+
+	// workers holds the hashers of the additional goroutines used to compute
+	// the root of the tree in parallel; it is nil if parallel hashing is
+	// disabled. Subtrees smaller than parallelThreshold leaves are always
+	// hashed sequentially.
+	workers           chan Hasher
+	parallelThreshold int
+
+	// store keeps the namespaced hashes of the nodes of the tree, keyed by the
+	// range of leaves they cover. It is populated by computeRoot and read by
+	// both computeRoot and buildRangeProof, so that neither the root nor the
+	// proofs require rehashing the subtrees that did not change. The stored
+	// nodes that become stale are dropped whenever leaves are appended.
+	store NodeStore
+	// storeLeaves indicates whether the leaf hashes are written to the store
+	// as well, as nodes covering a single leaf, so that the tree can be
+	// restored from the store, see NewFromNodeStore. It is only set for a
+	// store supplied through CustomNodeStore: the default store does not
+	// duplicate leafHashes.
+	storeLeaves bool
+	// storeDirty indicates whether nodes have been written to the store since
+	// it was last reset.
+	storeDirty bool
+	// storedEnd is the end of the rightmost range of the inner nodes written
+	// to the store since it was last reset. The nodes ending after it, e.g.,
+	// all the nodes while the store is cold, are computed without looking
+	// them up.
+	storedEnd int
+	// storeTrusted indicates whether the nodes in the store, if any, were
+	// computed from the leaves of the tree. It is false for a store supplied
+	// through CustomNodeStore until the store is first reset.
+	storeTrusted bool
+	// frozen indicates that the tree is the snapshot of a ReadOnlyTree: all
+	// its nodes are stored and none is ever computed, see Freeze.
+	frozen bool
 
 	// leaves holds the list of namespace-prefixed data elements that have been
 	// added to the tree, in the order of their insertion. Each
@@ -119,11 +233,10 @@ type NamespacedMerkleTree struct {
 	//  through the Root() or the computeLeafHashesIfNecessary methods.
 	leafHashes [][]byte
 
-	// namespaceRanges can be used to efficiently look up the range for an
-	// existing namespace without iterating through the leaves. The map key is
-	// the string representation of a namespace.ID  and the LeafRange indicates
-	// the range of the leaves matching that namespace ID in the tree
-	namespaceRanges map[string]LeafRange
+	// namespaces indexes the namespace IDs of the leaves, so that the range of
+	// the leaves of a namespace, or the position of an absent one, can be
+	// looked up without iterating through the leaves.
+	namespaces namespaceIndex
 	// minNID is the minimum namespace ID of the leaves
 	minNID namespace.ID
 	// maxNID is the maximum namespace ID of the leaves
@@ -170,17 +283,114 @@ func New(h hash.Hash, setters ...Option) *NamespacedMerkleTree {
 			reuseHasher.resetBuffer()
 		}
 	}
+	customStore := opts.NodeStore != nil
+	if !customStore {
+		opts.NodeStore = NewMemoryNodeStore()
+	}
 
 	return &NamespacedMerkleTree{
-		treeHasher:      opts.Hasher,
-		visit:           opts.NodeVisitor,
-		reuseBuffers:    opts.ReuseBuffers,
-		leaves:          make([][]byte, 0, opts.InitialCapacity),
-		leafHashes:      make([][]byte, 0, opts.InitialCapacity),
-		namespaceRanges: make(map[string]LeafRange),
-		minNID:          bytes.Repeat([]byte{0xFF}, int(opts.NamespaceIDSize)),
-		maxNID:          bytes.Repeat([]byte{0x00}, int(opts.NamespaceIDSize)),
+		treeHasher:         opts.Hasher,
+		visit:              opts.NodeVisitor,
+		workers:            newWorkerHashers(opts),
+		parallelThreshold:  defaultParallelThreshold,
+		reuseBuffers:       opts.ReuseBuffers,
+		shortAbsenceProofs: opts.ShortAbsenceProofs,
+		leaves:             make([][]byte, 0, opts.InitialCapacity),
+		leafHashes:         make([][]byte, 0, opts.InitialCapacity),
+		namespaces:         newNamespaceIndex(opts.NamespaceIDSize),
+		store:              opts.NodeStore,
+		storeLeaves:        customStore,
+		storeDirty:         customStore, // the supplied store may already hold nodes
+		storeTrusted:       !customStore,
+		minNID:             bytes.Repeat([]byte{0xFF}, int(opts.NamespaceIDSize)),
+		maxNID:             bytes.Repeat([]byte{0x00}, int(opts.NamespaceIDSize)),
+	}
+}
+
+// NewFromLeafHashes creates a namespaced Merkle tree from the namespaced hashes
+// of its leaves, as computed by hasher, e.g., for a light node that receives
+// the leaf hashes but not the leaves. The namespace size and the handling of
+// the maximum namespace are those of hasher. The tree can compute its root and
+// generate proofs, but the leaves themselves are not available: Get returns
+// nil, as for an absent namespace, while GetErr, GetWithProof and Push return
+// ErrLeafDataUnavailable. Leaves can only be pushed once the tree is Reset.
+// If hasher has a ValidateNodeFormat method, like NmtHasher, every leaf hash
+// is validated with it. NewFromLeafHashes returns ErrInvalidPushOrder if the
+// leaf hashes are not ordered by namespace ID.
+func NewFromLeafHashes(hasher Hasher, hashes [][]byte, setters ...Option) (*NamespacedMerkleTree, error) {
+	n := New(nil, append([]Option{
+		NamespaceIDSize(int(hasher.NamespaceSize())),
+		IgnoreMaxNamespace(hasher.IsMaxNamespaceIDIgnored()),
+		InitialCapacity(len(hashes)),
+	}, append(setters, CustomHasher(hasher))...)...)
+	if err := n.setLeafHashes(hashes); err != nil {
+		return nil, err
+	}
+	if err := n.putLeafHashes(0, n.Size()); err != nil {
+		return nil, err
 	}
+	return n, nil
+}
+
+// NewFromNodeStore restores a namespaced Merkle tree of the given size from
+// the nodes persisted in store, e.g., by a tree created with the
+// CustomNodeStore option. The leaf hashes are read from the store, so the
+// restored tree can compute its root and generate proofs without the leaves
+// being pushed again. However, the leaves themselves are not available, as
+// for a tree created with NewFromLeafHashes.
+// The setters must configure the same namespace size and hasher as those of
+// the tree that persisted the nodes.
+func NewFromNodeStore(h hash.Hash, store NodeStore, size int, setters ...Option) (*NamespacedMerkleTree, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("tree size %d shouldn't be strictly negative", size)
+	}
+	hashes := make([][]byte, size)
+	for i := range hashes {
+		leafHash, err := store.Get(LeafRange{Start: i, End: i + 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get leaf hash %d: %w", i, err)
+		}
+		hashes[i] = leafHash
+	}
+	n := New(h, append(setters, CustomNodeStore(store))...)
+	// the store holds the nodes of the restored tree
+	n.storeTrusted = true
+	n.storedEnd = size
+	if err := n.setLeafHashes(hashes); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// setLeafHashes sets the leaf hashes of an empty tree, without the leaves
+// themselves. It validates the format of the hashes if the hasher supports it,
+// and checks that they are ordered by namespace ID.
+func (n *NamespacedMerkleTree) setLeafHashes(hashes [][]byte) error {
+	validator, canValidate := n.treeHasher.(nodeFormatValidator)
+	n.leaves = nil
+	for i, leafHash := range hashes {
+		if canValidate {
+			if err := validator.ValidateNodeFormat(leafHash); err != nil {
+				return fmt.Errorf("invalid leaf hash %d: %w", i, err)
+			}
+		} else if len(leafHash) < int(n.NamespaceSize()) {
+			return fmt.Errorf("%w: leaf hash %d is too short", ErrInvalidNodeLen, i)
+		}
+		n.leafHashes = append(n.leafHashes, leafHash)
+		nID := n.leafNamespace(i)
+		if i > 0 && nID.Less(n.leafNamespace(i-1)) {
+			return fmt.Errorf("%w: leaf hash %d: last namespace: %x, got: %x", ErrInvalidPushOrder, i, n.leafNamespace(i-1), nID)
+		}
+		n.namespaces.push(nID)
+		n.updateMinMaxID(nID)
+	}
+	return nil
+}
+
+// nodeFormatValidator is implemented by the hashers that can validate the
+// format of a namespaced hash, such as NmtHasher.
+type nodeFormatValidator interface {
+	ValidateNodeFormat(node []byte) error
 }
 
 // Prove returns a NMT inclusion proof for the leaf at the supplied index. Note
@@ -194,11 +404,21 @@ func (n *NamespacedMerkleTree) Prove(index int) (Proof, error) {
 // Reset resets the tree data for subsequent reuse, also resets the allocated buffers for leafHashes,
 // so they won't be allocated again. We don't reuse buffers for `leaves`,
 // because the data is provided externally and not managed by the NMT.
+// Reset also removes all the nodes from the node store, and panics if the
+// store fails to do so. A tree created from leaf hashes, see NewFromLeafHashes,
+// holds the leaves pushed after it is reset.
 func (n *NamespacedMerkleTree) Reset() {
-	n.leaves = n.leaves[:0]
+	if n.hasLeafData() {
+		n.leaves = n.leaves[:0]
+	} else {
+		n.leaves = make([][]byte, 0, cap(n.leafHashes))
+	}
 	n.leafHashes = n.leafHashes[:0]
 	n.rawRoot = nil
-	n.namespaceRanges = map[string]LeafRange{}
+	n.namespaces.reset()
+	if err := n.dropNodes(); err != nil {
+		panic(fmt.Sprintf("failed to reset the node store: %v", err))
+	}
 	n.minNID = bytes.Repeat([]byte{0xFF}, int(n.treeHasher.NamespaceSize()))
 	n.maxNID = bytes.Repeat([]byte{0x00}, int(n.treeHasher.NamespaceSize()))
 	if reuseHasher, ok := n.treeHasher.(bufferedHasher); ok && n.reuseBuffers {
@@ -226,8 +446,6 @@ func (n *NamespacedMerkleTree) Reset() {
 // then ProveRange returns an ErrInvalidRange error. Any errors rather than ErrInvalidRange are irrecoverable and indicate an illegal state of the tree (n).
 func (n *NamespacedMerkleTree) ProveRange(start, end int) (Proof, error) {
 	isMaxNsIgnored := n.treeHasher.IsMaxNamespaceIDIgnored()
-	// TODO: store nodes and re-use the hashes instead recomputing parts of the
-	// tree here
 	if err := n.validateRange(start, end); err != nil {
 		return NewEmptyRangeProof(isMaxNsIgnored), err
 	}
@@ -235,7 +453,62 @@ func (n *NamespacedMerkleTree) ProveRange(start, end int) (Proof, error) {
 	if err != nil {
 		return Proof{}, err
 	}
-	return NewInclusionProof(start, end, proof, isMaxNsIgnored), nil
+	return NewInclusionProof(start, end, proof, isMaxNsIgnored).WithTreeSize(n.Size()), nil
+}
+
+// ProveRangeAt is like ProveRange, but proves the range [start, end) in the
+// tree of the first treeSize leaves, i.e., against the root the tree had when
+// it had treeSize leaves. An append-only log can use it to prove the inclusion
+// of a leaf against a root it published before more leaves were pushed. If
+// treeSize is not in [1, n.Size()] or the range is not within the first
+// treeSize leaves, ProveRangeAt returns an ErrInvalidRange error.
+func (n *NamespacedMerkleTree) ProveRangeAt(start, end, treeSize int) (Proof, error) {
+	isMaxNsIgnored := n.treeHasher.IsMaxNamespaceIDIgnored()
+	if treeSize < 1 || treeSize > n.Size() {
+		return NewEmptyRangeProof(isMaxNsIgnored), fmt.Errorf("%w: tree size %d is not in [1, %d]", ErrInvalidRange, treeSize, n.Size())
+	}
+	if start < 0 || start >= end || end > treeSize {
+		return NewEmptyRangeProof(isMaxNsIgnored), ErrInvalidRange
+	}
+	proof, err := n.buildRangesProofAt([]LeafRange{{Start: start, End: end}}, treeSize)
+	if err != nil {
+		return Proof{}, err
+	}
+	return NewInclusionProof(start, end, proof, isMaxNsIgnored).WithTreeSize(treeSize), nil
+}
+
+// ProveIndices returns a single Merkle inclusion proof for the leaves at the
+// supplied indices, which must be in ascending order without duplicates. The
+// nodes of the returned MultiProof are the roots of the subtrees that contain
+// none of the leaves but whose parent does, in the order of an in-order
+// traversal of the tree. This is the minimal set of nodes needed to recompute
+// the root from the leaves: the nodes shared by the proofs of the individual
+// leaves are only included once.
+//
+// If an index is out of range, or if the indices are empty or not strictly
+// increasing, ProveIndices returns an ErrInvalidRange error. Any errors rather
+// than ErrInvalidRange are irrecoverable and indicate an illegal state of the
+// tree (n).
+func (n *NamespacedMerkleTree) ProveIndices(indices []int) (MultiProof, error) {
+	isMaxNsIgnored := n.treeHasher.IsMaxNamespaceIDIgnored()
+	if len(indices) == 0 {
+		return MultiProof{}, fmt.Errorf("%w: no indices to prove", ErrInvalidRange)
+	}
+	ranges := make([]LeafRange, 0, len(indices))
+	for i, index := range indices {
+		if index < 0 || index >= n.Size() {
+			return MultiProof{}, fmt.Errorf("%w: index %d, tree size %d", ErrInvalidRange, index, n.Size())
+		}
+		if i > 0 && index <= indices[i-1] {
+			return MultiProof{}, fmt.Errorf("%w: indices must be strictly increasing: %d is followed by %d", ErrInvalidRange, indices[i-1], index)
+		}
+		ranges = append(ranges, LeafRange{Start: index, End: index + 1})
+	}
+	nodes, err := n.buildRangesProof(mergeRanges(ranges))
+	if err != nil {
+		return MultiProof{}, err
+	}
+	return NewMultiProof(slices.Clone(indices), nodes, isMaxNsIgnored), nil
 }
 
 // ProveNamespace returns a range proof for the given NamespaceID.
@@ -255,7 +528,9 @@ func (n *NamespacedMerkleTree) ProveRange(start, end int) (Proof, error) {
 // proof. the leafHash field of the returned Proof will contain the namespaced
 // hash of such leaf. The start and end fields of the Proof are set to the
 // indices of the identified leaf. The start field is set to the index of the
-// leaf, and the end field is set to the index of the leaf + 1.
+// leaf, and the end field is set to the index of the leaf + 1. If the tree was
+// created with the ShortAbsenceProofs option, the proof holds the highest
+// inner node whose leftmost leaf is that leaf instead.
 //
 // case 3) In case the underlying tree contains leaves with the given namespace
 // their start and end (end is non-inclusive) index will be returned together
@@ -281,41 +556,207 @@ func (n *NamespacedMerkleTree) ProveNamespace(nID namespace.ID) (Proof, error) {
 	if err != nil {
 		return Proof{}, fmt.Errorf("failed to get root: %w", err)
 	}
+
+	r, found := n.namespaceProofRange(nID, root)
+	// case 1)
+	if r.Start == r.End {
+		return NewEmptyRangeProof(isMaxNsIgnored), nil
+	}
+
+	// case 2) and 3) At this point we either found leaves with the namespace
+	// nID in the tree or calculated the range it would be in (to generate a
+	// proof of absence and to return the corresponding leaf hashes).
+	proof, err := n.buildRangeProof(r.Start, r.End)
+	if err != nil {
+		return Proof{}, err
+	}
+
+	if found {
+		return NewInclusionProof(r.Start, r.End, proof, isMaxNsIgnored).WithTreeSize(n.Size()), nil
+	}
+	if n.shortAbsenceProofs {
+		return n.shortAbsenceProof(r.Start)
+	}
+
+	return NewAbsenceProof(r.Start, r.End, proof, n.leafHashes[r.Start], isMaxNsIgnored).WithTreeSize(n.Size()), nil
+}
+
+// ProveNamespaces returns a single proof for several namespaces of the tree,
+// which must be given in ascending order without duplicates. For each
+// namespace, the proof holds the same range as the Proof returned by
+// ProveNamespace, i.e., the range of the leaves of the namespace, the leaf
+// proving its absence, or an empty range if the namespace is outside the
+// range of the tree. The nodes shared by the proofs of several namespaces are
+// only included once.
+// Any error returned by this method other than an invalid namespace ID is
+// irrecoverable and indicates an illegal state of the tree (n).
+func (n *NamespacedMerkleTree) ProveNamespaces(nIDs []namespace.ID) (MultiNamespaceProof, error) {
+	isMaxNsIgnored := n.treeHasher.IsMaxNamespaceIDIgnored()
+	for i, nID := range nIDs {
+		if nID.Size() != n.NamespaceSize() {
+			return MultiNamespaceProof{}, fmt.Errorf("namespace ID size (%d) does not match the namespace size of the tree (%d)", nID.Size(), n.NamespaceSize())
+		}
+		if i > 0 && !nIDs[i-1].Less(nID) {
+			return MultiNamespaceProof{}, fmt.Errorf("namespace IDs must be strictly increasing: %x is followed by %x", nIDs[i-1], nID)
+		}
+	}
+
+	proof := MultiNamespaceProof{
+		ranges:                  make([]LeafRange, len(nIDs)),
+		leafHashes:              make([][]byte, len(nIDs)),
+		isMaxNamespaceIDIgnored: isMaxNsIgnored,
+	}
+	// an empty tree does not contain any namespace
+	if n.Size() == 0 {
+		return proof, nil
+	}
+
+	root, err := n.Root()
+	if err != nil {
+		return MultiNamespaceProof{}, fmt.Errorf("failed to get root: %w", err)
+	}
+	for i, nID := range nIDs {
+		r, found := n.namespaceProofRange(nID, root)
+		proof.ranges[i] = r
+		if !found && r.Start < r.End {
+			proof.leafHashes[i] = n.leafHashes[r.Start]
+		}
+	}
+
+	ranges := mergeRanges(proof.ranges)
+	if len(ranges) > 0 {
+		proof.nodes, err = n.buildRangesProof(ranges)
+		if err != nil {
+			return MultiNamespaceProof{}, err
+		}
+	}
+	return proof, nil
+}
+
+// ProveNamespaceRange returns a range proof for all the namespaces in the
+// interval [lo, hi], i.e., a proof of the leaves whose namespace ID is within
+// the interval, which also proves that no other leaf of the tree is. Similarly
+// to ProveNamespace:
+//
+// case 1) If the interval is out of the range of the tree's min and max
+// namespace, ProveNamespaceRange returns an empty Proof.
+//
+// case 2) If the tree does not have any leaf within the interval, this will be
+// proven by returning an absence Proof of the leaf of the tree with the
+// smallest namespace ID that is larger than hi.
+//
+// case 3) Otherwise, the returned Proof covers the range of the leaves within
+// the interval, which may belong to several namespaces.
+//
+// ProveNamespaceRange returns an error if lo is larger than hi, or if their
+// size does not match the namespace size of the tree. Any other error is
+// irrecoverable and indicates an illegal state of the tree (n).
+func (n *NamespacedMerkleTree) ProveNamespaceRange(lo, hi namespace.ID) (Proof, error) {
+	isMaxNsIgnored := n.treeHasher.IsMaxNamespaceIDIgnored()
+	if lo.Size() != n.NamespaceSize() || hi.Size() != n.NamespaceSize() {
+		return Proof{}, fmt.Errorf("namespace ID sizes (%d, %d) do not match the namespace size of the tree (%d)", lo.Size(), hi.Size(), n.NamespaceSize())
+	}
+	if hi.Less(lo) {
+		return Proof{}, fmt.Errorf("invalid namespace interval: %x is larger than %x", lo, hi)
+	}
+
+	// check if the tree is empty
+	if n.Size() == 0 {
+		return NewEmptyRangeProof(isMaxNsIgnored), nil
+	}
+
+	root, err := n.Root()
+	if err != nil {
+		return Proof{}, fmt.Errorf("failed to get root: %w", err)
+	}
+	treeMinNs := namespace.ID(MinNamespace(root, n.NamespaceSize()))
+	treeMaxNs := namespace.ID(MaxNamespace(root, n.NamespaceSize()))
+
+	// case 1)
+	if hi.Less(treeMinNs) || treeMaxNs.Less(lo) {
+		return NewEmptyRangeProof(isMaxNsIgnored), nil
+	}
+
+	// the leaves are sorted by namespace ID
+	proofStart := sort.Search(n.Size(), func(i int) bool {
+		return lo.LessOrEqual(n.leafNamespace(i))
+	})
+	proofEnd := sort.Search(n.Size(), func(i int) bool {
+		return hi.Less(n.leafNamespace(i))
+	})
+
+	// case 2) the leaf at proofStart is the first one after the interval
+	found := proofStart < proofEnd
+	if !found {
+		proofEnd = proofStart + 1
+	}
+
+	proof, err := n.buildRangeProof(proofStart, proofEnd)
+	if err != nil {
+		return Proof{}, err
+	}
+	if found {
+		return NewInclusionProof(proofStart, proofEnd, proof, isMaxNsIgnored).WithTreeSize(n.Size()), nil
+	}
+	return NewAbsenceProof(proofStart, proofEnd, proof, n.leafHashes[proofStart], isMaxNsIgnored).WithTreeSize(n.Size()), nil
+}
+
+// namespaceProofRange returns the range of leaves proving the presence or the
+// absence of the namespace nID in the tree with the given root, see
+// ProveNamespace:
+// 1) an empty range if nID is out of the namespace range of the tree,
+// 2) the leaf proving the absence of nID if it is within the namespace range
+// of the tree but has no leaves,
+// 3) the range of the leaves of nID otherwise, in which case found is true.
+func (n *NamespacedMerkleTree) namespaceProofRange(nID namespace.ID, root []byte) (r LeafRange, found bool) {
 	// extract the min and max namespace of the tree from the root
 	treeMinNs := namespace.ID(MinNamespace(root, n.NamespaceSize()))
 	treeMaxNs := namespace.ID(MaxNamespace(root, n.NamespaceSize()))
 
-	// case 1) In the cases (n.nID < treeMinNs) or (treeMaxNs < nID), return empty
-	// range proof
+	// case 1) In the cases (n.nID < treeMinNs) or (treeMaxNs < nID), return
+	// an empty range
 	if nID.Less(treeMinNs) || treeMaxNs.Less(nID) {
-		return NewEmptyRangeProof(isMaxNsIgnored), nil
+		return LeafRange{}, false
 	}
 
 	// find the range of indices of leaves with the given nID
-	found, proofStart, proofEnd := n.foundInRange(nID)
+	found, start, end := n.foundInRange(nID)
 
 	// case 2)
 	if !found {
 		// To generate a proof for an absence we calculate the position of the
 		// leaf that is in the place of where the namespace would be in:
-		proofStart = n.calculateAbsenceIndex(nID)
-		proofEnd = proofStart + 1
+		start = n.calculateAbsenceIndex(nID)
+		end = start + 1
 	}
+	return LeafRange{Start: start, End: end}, found
+}
 
-	// case 3) At this point we either found leaves with the namespace nID in
-	// the tree or calculated the range it would be in (to generate a proof of
-	// absence and to return the corresponding leaf hashes).
-
-	proof, err := n.buildRangeProof(proofStart, proofEnd)
+// shortAbsenceProof returns the absence proof of a namespace whose leaves
+// would be at index, which must be larger than 0, using the highest node of
+// the tree whose leftmost leaf is at index, see ShortAbsenceProofs. The
+// namespace ID of that leaf is larger than the absent namespace, and those of
+// the leaves on its left are smaller, so that the node proves the absence as
+// well as the leaf itself.
+func (n *NamespacedMerkleTree) shortAbsenceProof(index int) (Proof, error) {
+	// the nodes of a level cover 1<<level aligned leaves, except for the last
+	// one; the node of the next level starting at index covers more leaves if
+	// index is aligned to it and the tree has leaves beyond the current node
+	level := 0
+	for index%(2<<level) == 0 && index+(1<<level) < n.Size() {
+		level++
+	}
+	end := min(index+(1<<level), n.Size())
+	nodes, err := n.buildRangeProof(index, end)
 	if err != nil {
 		return Proof{}, err
 	}
-
-	if found {
-		return NewInclusionProof(proofStart, proofEnd, proof, isMaxNsIgnored), nil
+	node, err := n.nodeHash(index, end)
+	if err != nil {
+		return Proof{}, err
 	}
-
-	return NewAbsenceProof(proofStart, proofEnd, proof, n.leafHashes[proofStart], isMaxNsIgnored), nil
+	position := index >> level
+	return NewAbsenceProof(position, position+1, nodes, node, n.treeHasher.IsMaxNamespaceIDIgnored()), nil
 }
 
 // validateRange validates the range [start, end) against the size of the tree.
@@ -330,110 +771,151 @@ func (n *NamespacedMerkleTree) validateRange(start, end int) error {
 // buildRangeProof returns the nodes (as byte slices) in the range proof of the
 // supplied range i.e., [proofStart, proofEnd) where proofEnd is non-inclusive.
 // The nodes are ordered according to in order traversal of the namespaced tree.
+// The nodes are served from the node store, which is populated by computing
+// the root of the tree if necessary.
 // Any errors returned by this method are irrecoverable and indicate an illegal state of the tree (n).
 func (n *NamespacedMerkleTree) buildRangeProof(proofStart, proofEnd int) ([][]byte, error) {
-	proof := [][]byte{} // it is the list of nodes hashes (as byte slices) with no index
-	var recurse func(start, end int, includeNode bool) ([]byte, error)
-
 	// validate the range
 	if err := n.validateRange(proofStart, proofEnd); err != nil {
 		return nil, err
 	}
+	return n.buildRangesProof([]LeafRange{{Start: proofStart, End: proofEnd}})
+}
 
-	// start, end are indices of leaves in the tree hence they should be within
-	// the size of the tree i.e., less than or equal to n.Size()
-	// includeNode indicates whether the hash of the current subtree (covering
-	// the supplied range i.e., [start, end)) or one of its constituent subtrees
-	// should be part of the proof
-	recurse = func(start, end int, includeNode bool) ([]byte, error) {
-		if start >= n.Size() {
-			return nil, nil
-		}
-
-		// reached a leaf
-		if end-start == 1 {
-			leafHash := n.leafHashes[start]
-			// if the index of the leaf node is out of the queried range i.e. ,
-			// [proofStart, proofEnd) and if the leaf is required as part of the
-			// proof i.e., includeNode == true
-			if (start < proofStart || start >= proofEnd) && includeNode {
-				// add the leafHash to the proof
-				proof = append(proof, leafHash)
-			}
-			// if the index of the leaf is within the queried range i.e.,
-			// [proofStart, proofEnd] OR if the leaf is not required as part of
-			// the proof i.e., includeNode == false
-			return leafHash, nil
-		}
-
-		// newIncludeNode indicates whether one of the subtrees of the current
-		// subtree [start, end) may have an overlap with the queried proof range
-		// i.e., [proofStart, proofEnd)
-		newIncludeNode := includeNode
-		// check whether the subtree representing the [start, end) range of
-		// leaves has overlap with the queried proof range i.e., [proofStart,
-		// proofEnd) if there is no overlap
-		if (end <= proofStart || start >= proofEnd) && includeNode {
-			// setting newIncludeNode to false indicates that none of the
-			// subtrees (left and right) of the current subtree are required for
-			// the proof because the range of the leaves they cover have no
-			// overlap with the queried proof range i.e., [proofStart, proofEnd)
-			newIncludeNode = false
-		}
-
-		// recursively get left and right subtree
-		k := getSplitPoint(end - start)
+// buildRangesProof returns the nodes (as byte slices) in the proof of the
+// supplied ranges, i.e., the roots of the subtrees that do not overlap any of
+// the ranges but whose parent does. The ranges must be valid, sorted and
+// non-overlapping. The nodes are ordered according to in order traversal of
+// the namespaced tree, so that the nodes shared by the proofs of several
+// ranges are only included once.
+func (n *NamespacedMerkleTree) buildRangesProof(ranges []LeafRange) ([][]byte, error) {
+	return n.buildRangesProofAt(ranges, n.Size())
+}
 
-		left, err := recurse(start, start+k, newIncludeNode)
-		if err != nil {
-			return nil, err
-		}
-		right, err := recurse(start+k, end, newIncludeNode)
-		if err != nil {
-			return nil, err
+// buildRangesProofAt is like buildRangesProof, but builds the proof in the
+// tree of the first treeSize leaves, which must be in [1, n.Size()].
+func (n *NamespacedMerkleTree) buildRangesProofAt(ranges []LeafRange, treeSize int) ([][]byte, error) {
+	proof := [][]byte{} // it is the list of nodes hashes (as byte slices) with no index
+	var recurse func(start, end int) error
+
+	// make sure all the nodes of the tree are computed and stored
+	if _, err := n.Root(); err != nil {
+		return nil, err
+	}
+
+	// start, end are indices of leaves in the tree hence they should be within
+	// the size of the tree i.e., less than or equal to treeSize
+	recurse = func(start, end int) error {
+		// the subtree does not exist
+		if start >= treeSize {
+			return nil
 		}
 
-		// only right leaf/subtree can be non-existent
-		var hash []byte
-		if right == nil {
-			hash = left
-		} else {
-			var err error
-			hash, err = n.treeHasher.HashNode(left, right)
-			if err != nil { // if HashNode returns an error, it is a bug
-				return nil, err // this should never happen if the Push method is used to add leaves to the tree
+		// if the subtree representing the [start, end) range of leaves has no
+		// overlap with the queried ranges, its hash is part of the proof, but
+		// none of its descendants are
+		overlaps, covered := rangesOverlap(ranges, start, end)
+		if !overlaps {
+			// the subtree may be cut short by the size of the tree
+			hash, err := n.nodeHash(start, min(end, treeSize))
+			if err != nil {
+				return err
 			}
+			proof = append(proof, hash)
+			return nil
 		}
 
-		// if the hash of the subtree representing [start, end) should be part
-		// of the proof but not its left and right subtrees
-		if includeNode && !newIncludeNode {
-			proof = append(proof, hash)
+		// if the subtree is fully covered by one of the queried ranges, none
+		// of its nodes are part of the proof
+		if covered {
+			return nil
 		}
 
-		return hash, nil
+		// recursively visit left and right subtree
+		k := getSplitPoint(end - start)
+		if err := recurse(start, start+k); err != nil {
+			return err
+		}
+		return recurse(start+k, end)
 	}
 
-	fullTreeSize := getSplitPoint(n.Size()) * 2
+	fullTreeSize := getSplitPoint(treeSize) * 2
 	if fullTreeSize < 1 {
 		fullTreeSize = 1
 	}
-	if _, err := recurse(0, fullTreeSize, true); err != nil {
+	if err := recurse(0, fullTreeSize); err != nil {
 		return nil, err
 	}
 	return proof, nil
 }
 
-// Get returns leaves for the given namespace.ID.
+// nodeHash returns the namespaced hash of the node covering the leaves in the
+// range [start, end). The hash is looked up in the node store and is only
+// computed if it is not stored.
+func (n *NamespacedMerkleTree) nodeHash(start, end int) ([]byte, error) {
+	if end-start == 1 {
+		return n.leafHashes[start], nil
+	}
+	hash, found, err := n.getNode(start, end)
+	switch {
+	case err != nil:
+		return nil, err
+	case found:
+		return hash, nil
+	case n.frozen:
+		return nil, fmt.Errorf("%w: [%d, %d)", ErrNodeNotFound, start, end)
+	}
+	return n.computeRoot(start, end)
+}
+
+// getNode looks up the node covering the leaves in the range [start, end) in
+// the node store, and reports whether it is stored. The default store is
+// looked up directly, so that a miss does not go through ErrNodeNotFound.
+func (n *NamespacedMerkleTree) getNode(start, end int) (hash []byte, found bool, err error) {
+	r := LeafRange{Start: start, End: end}
+	if s, ok := n.store.(*MemoryNodeStore); ok {
+		hash, found = s.lookup(r)
+		return hash, found, nil
+	}
+	hash, err = n.store.Get(r)
+	switch {
+	case errors.Is(err, ErrNodeNotFound):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to get node [%d, %d): %w", start, end, err)
+	}
+	return hash, true, nil
+}
+
+// Get returns leaves for the given namespace.ID. It returns nil if the tree
+// does not hold the leaves themselves, see NewFromLeafHashes, which cannot be
+// told apart from a namespace without leaves: GetErr reports it as an error.
 func (n *NamespacedMerkleTree) Get(nID namespace.ID) [][]byte {
+	if !n.hasLeafData() {
+		return nil
+	}
 	_, start, end := n.foundInRange(nID)
 	return n.leaves[start:end]
 }
 
+// GetErr is like Get, but returns ErrLeafDataUnavailable if the tree does not
+// hold the leaves themselves, see NewFromLeafHashes.
+func (n *NamespacedMerkleTree) GetErr(nID namespace.ID) ([][]byte, error) {
+	if !n.hasLeafData() {
+		return nil, ErrLeafDataUnavailable
+	}
+	return n.Get(nID), nil
+}
+
 // GetWithProof is a convenience method returns leaves for the given
 // namespace.ID together with the proof for that namespace. It returns the same
 // result as calling the combination of Get(nid) and ProveNamespace(nid).
+// It returns ErrLeafDataUnavailable if the tree does not hold its leaves, see
+// NewFromLeafHashes.
 func (n *NamespacedMerkleTree) GetWithProof(nID namespace.ID) ([][]byte, Proof, error) {
+	if !n.hasLeafData() {
+		return nil, Proof{}, ErrLeafDataUnavailable
+	}
 	data := n.Get(nID)
 	proof, err := n.ProveNamespace(nID)
 	return data, proof, err
@@ -443,29 +925,16 @@ func (n *NamespacedMerkleTree) GetWithProof(nID namespace.ID) ([][]byte, Proof,
 // namespace ID is the smallest namespace ID larger than nID and 2) the
 // namespace ID of the leaf to the left of it is smaller than the nID.
 func (n *NamespacedMerkleTree) calculateAbsenceIndex(nID namespace.ID) int {
-	nidSize := n.treeHasher.NamespaceSize()
-	var prevLeaf []byte
-
-	for index, curLeaf := range n.leaves {
-		if index == 0 {
-			prevLeaf = curLeaf
-			continue
-		}
-		prevNs := namespace.ID(prevLeaf[:nidSize])
-		currentNs := curLeaf[:nidSize]
-		// Note that here we would also care for the case current < nId < prevNs
-		// but we only allow pushing leaves with ascending namespaces; i.e.
-		// prevNs <= currentNs is always true. Also we only check for strictly
-		// smaller: prev < nid < current because if we either side was equal, we
-		// would have found the namespace before.
-		if prevNs.Less(nID) && nID.Less(currentNs) {
-			return index
-		}
-		prevLeaf = curLeaf
+	// the leaf is the first one of the smallest namespace larger than nID,
+	// unless nID is present in the tree; if the namespace is the smallest
+	// one, no leaf is on the left of it
+	i, found := n.namespaces.search(nID)
+	if found || i == 0 || i == n.namespaces.len() {
+		// the case (nID < minNID) or (maxNID < nID) should be handled before
+		// calling this private helper!
+		panic("calculateAbsenceIndex() called although (nID < minNID) or (maxNID < nID) for provided nID")
 	}
-	// the case (nID < minNID) or (maxNID < nID) should be handled before
-	// calling this private helper!
-	panic("calculateAbsenceIndex() called although (nID < minNID) or (maxNID < nID) for provided nID")
+	return n.namespaces.starts[i]
 }
 
 // foundInRange returns a range of leaves in the namespace tree with the
@@ -474,12 +943,29 @@ func (n *NamespacedMerkleTree) calculateAbsenceIndex(nID namespace.ID) int {
 // non-inclusive, meaning it does not include the leaf at that index in the
 // range. If no leaves are found, foundInRange returns (false, 0, 0).
 func (n *NamespacedMerkleTree) foundInRange(nID namespace.ID) (found bool, startIndex int, endIndex int) {
-	// This is a faster version of this code snippet:
-	// https://github.com/celestiaorg/celestiaorg-prototype/blob/2aeca6f55ad389b9d68034a0a7038f80a8d2982e/simpleblock.go#L106-L117
-	foundRng, found := n.namespaceRanges[string(nID)]
+	foundRng, found := n.namespaces.lookup(nID)
 	return found, foundRng.Start, foundRng.End
 }
 
+// NeighborNamespaces returns the largest namespace ID of the leaves of the tree
+// that is smaller than nID, and the smallest one that is larger than nID,
+// whether or not the tree has leaves of the namespace nID. Either is nil if
+// there is no such namespace ID. The lookup takes a logarithmic time in the
+// number of namespaces of the tree.
+func (n *NamespacedMerkleTree) NeighborNamespaces(nID namespace.ID) (prev, next namespace.ID) {
+	i, found := n.namespaces.search(nID)
+	if i > 0 {
+		prev = bytes.Clone(n.namespaces.nID(i - 1))
+	}
+	if found {
+		i++
+	}
+	if i < n.namespaces.len() {
+		next = bytes.Clone(n.namespaces.nID(i))
+	}
+	return prev, next
+}
+
 // NamespaceSize returns the underlying namespace size. Note that all namespaced
 // data is expected to have the same namespace size.
 func (n *NamespacedMerkleTree) NamespaceSize() namespace.IDSize {
@@ -493,6 +979,9 @@ func (n *NamespacedMerkleTree) NamespaceSize() namespace.IDSize {
 // the namespace ID compared to the previously inserted data (i.e., it is not
 // lexicographically sorted by namespace ID).
 func (n *NamespacedMerkleTree) Push(namespacedData namespace.PrefixedData) error {
+	if !n.hasLeafData() {
+		return ErrLeafDataUnavailable
+	}
 	nID, err := n.validateAndExtractNamespace(namespacedData)
 	if err != nil {
 		return err
@@ -507,10 +996,67 @@ func (n *NamespacedMerkleTree) Push(namespacedData namespace.PrefixedData) error
 	// update relevant "caches":
 	n.leaves = append(n.leaves, namespacedData)
 	n.leafHashes = append(n.leafHashes, res)
-	n.updateNamespaceRanges()
+	n.namespaces.push(nID)
 	n.updateMinMaxID(nID)
 	n.rawRoot = nil
-	return nil
+	if err := n.dropSpineNodes(n.Size() - 1); err != nil {
+		return err
+	}
+	return n.putLeafHashes(n.Size()-1, n.Size())
+}
+
+// PushBatch adds the namespaced data items of leaves to the tree, in order, like
+// calling Push for each of them. The namespace IDs of the whole batch are
+// validated before any leaf is hashed, and if the tree was created with the
+// ParallelHashing option, the leaves of large batches are hashed concurrently
+// by its workers. PushBatch is atomic: if any leaf is not namespace-prefixed,
+// is out of order, or cannot be hashed, PushBatch returns the error of the
+// first such leaf, e.g., ErrInvalidLeafLen or ErrInvalidPushOrder, and the
+// tree is left unchanged.
+func (n *NamespacedMerkleTree) PushBatch(leaves []namespace.PrefixedData) error {
+	if !n.hasLeafData() {
+		return ErrLeafDataUnavailable
+	}
+	if len(leaves) == 0 {
+		return nil
+	}
+	nidSize := int(n.NamespaceSize())
+	var prevNID namespace.ID
+	if n.Size() > 0 {
+		prevNID = n.leafNamespace(n.Size() - 1)
+	}
+	for i, leaf := range leaves {
+		if len(leaf) < nidSize {
+			return fmt.Errorf("%w: leaf %d: got: %v, want >= %v", ErrInvalidLeafLen, i, len(leaf), nidSize)
+		}
+		nID := namespace.ID(leaf[:nidSize])
+		if nID.Less(prevNID) {
+			return fmt.Errorf("%w: leaf %d: last namespace: %x, pushed: %x", ErrInvalidPushOrder, i, prevNID, nID)
+		}
+		prevNID = nID
+	}
+
+	hashes, err := n.hashLeaves(leaves)
+	if err != nil {
+		return err
+	}
+
+	// update relevant "caches":
+	size := n.Size()
+	for _, leaf := range leaves {
+		n.leaves = append(n.leaves, leaf)
+		n.namespaces.push(namespace.ID(leaf[:nidSize]))
+	}
+	n.leafHashes = append(n.leafHashes, hashes...)
+	// the leaves are ordered, so that only the first and the last one may
+	// extend the namespace range of the tree
+	n.updateMinMaxID(namespace.ID(leaves[0][:nidSize]))
+	n.updateMinMaxID(namespace.ID(leaves[len(leaves)-1][:nidSize]))
+	n.rawRoot = nil
+	if err := n.dropSpineNodes(size); err != nil {
+		return err
+	}
+	return n.putLeafHashes(size, n.Size())
 }
 
 // Root calculates the namespaced Merkle Tree's root based on the data that has
@@ -560,6 +1106,9 @@ func (n *NamespacedMerkleTree) MaxNamespace() (namespace.ID, error) {
 // create out of order trees. The default hasher will fail for trees that are
 // out of order.
 func (n *NamespacedMerkleTree) ForceAddLeaf(leaf namespace.PrefixedData) error {
+	if !n.hasLeafData() {
+		return ErrLeafDataUnavailable
+	}
 	nID := namespace.ID(leaf[:n.NamespaceSize()])
 	// compute the leaf hash
 	res, err := n.treeHasher.HashLeaf(leaf)
@@ -570,16 +1119,88 @@ func (n *NamespacedMerkleTree) ForceAddLeaf(leaf namespace.PrefixedData) error {
 	// update relevant "caches":
 	n.leaves = append(n.leaves, leaf)
 	n.leafHashes = append(n.leafHashes, res)
-	n.updateNamespaceRanges()
+	n.namespaces.push(nID)
 	n.updateMinMaxID(nID)
 	n.rawRoot = nil
-	return nil
+	if err := n.dropSpineNodes(n.Size() - 1); err != nil {
+		return err
+	}
+	return n.putLeafHashes(n.Size()-1, n.Size())
+}
+
+// SetLeaf replaces the leaf at the given index with the namespaced data. The
+// namespace ID of the data must not be smaller than the one of the previous
+// leaf, nor larger than the one of the next leaf, so that the leaves remain
+// ordered by namespace ID. Only the nodes on the path from the leaf to the
+// root are invalidated, so that the next root computation rehashes a
+// logarithmic number of nodes.
+// SetLeaf returns ErrInvalidRange if the index is out of range, and the same
+// errors as Push if the data is not namespace-prefixed or out of order.
+func (n *NamespacedMerkleTree) SetLeaf(index int, data namespace.PrefixedData) error {
+	if !n.hasLeafData() {
+		return ErrLeafDataUnavailable
+	}
+	if index < 0 || index >= n.Size() {
+		return fmt.Errorf("%w: index %d, tree size %d", ErrInvalidRange, index, n.Size())
+	}
+	nidSize := int(n.NamespaceSize())
+	if len(data) < nidSize {
+		return fmt.Errorf("%w: got: %v, want >= %v", ErrInvalidLeafLen, len(data), nidSize)
+	}
+	nID := namespace.ID(data[:nidSize])
+	if index > 0 {
+		if prevNID := n.leafNamespace(index - 1); nID.Less(prevNID) {
+			return fmt.Errorf("%w: previous namespace: %x, set: %x", ErrInvalidPushOrder, prevNID, nID)
+		}
+	}
+	if index < n.Size()-1 {
+		if nextNID := n.leafNamespace(index + 1); nextNID.Less(nID) {
+			return fmt.Errorf("%w: next namespace: %x, set: %x", ErrInvalidPushOrder, nextNID, nID)
+		}
+	}
+
+	// compute the leaf hash
+	res, err := n.treeHasher.HashLeaf(data)
+	if err != nil {
+		return err
+	}
+
+	oldNID := n.leafNamespace(index)
+	n.leaves[index] = data
+	n.leafHashes[index] = res
+	if !oldNID.Equal(nID) {
+		n.namespaces.set(index, oldNID, nID)
+		// the leaves are ordered, so that the first and the last ones hold
+		// the minimum and maximum namespace IDs
+		n.minNID = n.leafNamespace(0)
+		n.maxNID = n.leafNamespace(n.Size() - 1)
+	}
+	n.rawRoot = nil
+	if err := n.dropPathNodes(index); err != nil {
+		return err
+	}
+	return n.putLeafHashes(index, index+1)
 }
 
 // computeRoot calculates the namespace Merkle root for a tree/sub-tree that
 // encompasses the leaves within the range of [start, end).
 // Any errors returned by this method are irrecoverable and indicate an illegal state of the tree (n).
 func (n *NamespacedMerkleTree) computeRoot(start, end int) ([]byte, error) {
+	// the nodes computed below are written to the store
+	n.storeDirty = true
+	hash, err := n.computeSubtreeRoot(n.treeHasher, start, end)
+	if err != nil {
+		return nil, err
+	}
+	n.storedEnd = max(n.storedEnd, end)
+	return hash, nil
+}
+
+// computeSubtreeRoot calculates the namespace Merkle root for a tree/sub-tree
+// that encompasses the leaves within the range of [start, end) using the hasher
+// h. With parallel hashing, it may be called concurrently with distinct
+// hashers for disjoint ranges.
+func (n *NamespacedMerkleTree) computeSubtreeRoot(h Hasher, start, end int) ([]byte, error) {
 	// in computeRoot, start may be equal to end which indicates an empty tree hence empty root.
 	// Due to this, we need to perform custom range check instead of using validateRange() in which start=end is considered invalid.
 	if start < 0 || start > end || end > n.Size() {
@@ -587,7 +1208,7 @@ func (n *NamespacedMerkleTree) computeRoot(start, end int) ([]byte, error) {
 	}
 	switch end - start {
 	case 0:
-		rootHash := n.treeHasher.EmptyRoot()
+		rootHash := h.EmptyRoot()
 		// check visit for nil is better than having noop due to additional alloc on variadic parameters
 		if n.visit != nil {
 			n.visit(rootHash)
@@ -596,30 +1217,201 @@ func (n *NamespacedMerkleTree) computeRoot(start, end int) ([]byte, error) {
 	case 1:
 		leafHash := n.leafHashes[start]
 		if n.visit != nil {
-			n.visit(leafHash, n.leaves[start])
+			if n.hasLeafData() {
+				n.visitNode(leafHash, n.leaves[start])
+			} else {
+				n.visitNode(leafHash)
+			}
 		}
 		return leafHash, nil
 	default:
-		k := getSplitPoint(end - start)
-		left, err := n.computeRoot(start, start+k)
-		if err != nil { // this should never happen since leaves are added through the Push method, during which leaves formats are validated and their namespace IDs are checked to be sequential.
-			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start, start+k, err)
+		// only the nodes of the current tree are stored, see isCurrentNode
+		current := n.isCurrentNode(start, end)
+		// reuse the node if it has been computed already, e.g., when only
+		// leaves on its right have been appended since the last computation
+		if current && end <= n.storedEnd {
+			hash, found, err := n.getNode(start, end)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				return hash, nil
+			}
 		}
-		right, err := n.computeRoot(start+k, end)
-		if err != nil { // this should never happen since leaves are added through the Push method, during which leaves formats are validated and their namespace IDs are checked to be sequential.
-			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start+k, end, err)
+		k := getSplitPoint(end - start)
+		left, right, err := n.computeChildren(h, start, start+k, end)
+		if err != nil {
+			return nil, err
 		}
-		hash, err := n.treeHasher.HashNode(left, right)
+		hash, err := h.HashNode(left, right)
 		if err != nil { // this error should never happen since leaves are added through the Push method, during which leaves formats are validated and their namespace IDs are checked to be sequential.
 			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", left, right, err)
 		}
 		if n.visit != nil {
-			n.visit(hash, left, right)
+			n.visitNode(hash, left, right)
+		}
+		if current {
+			if err := n.putNode(start, end, hash); err != nil {
+				return nil, err
+			}
 		}
 		return hash, nil
 	}
 }
 
+// computeChildren calculates the roots of the left [start, split) and right
+// [split, end) subtrees of a node. If a worker is available and the node is
+// large enough, the left subtree is hashed by the worker concurrently with the
+// right one.
+func (n *NamespacedMerkleTree) computeChildren(h Hasher, start, split, end int) (left, right []byte, err error) {
+	if worker := n.acquireWorker(end - start); worker != nil {
+		return n.computeChildrenParallel(h, worker, start, split, end)
+	}
+	left, err = n.computeSubtreeRoot(h, start, split)
+	if err != nil { // this should never happen since leaves are added through the Push method, during which leaves formats are validated and their namespace IDs are checked to be sequential.
+		return nil, nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start, split, err)
+	}
+	right, err = n.computeSubtreeRoot(h, split, end)
+	if err != nil { // this should never happen since leaves are added through the Push method, during which leaves formats are validated and their namespace IDs are checked to be sequential.
+		return nil, nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", split, end, err)
+	}
+	return left, right, nil
+}
+
+// computeChildrenParallel is like computeChildren, but hashes the left
+// subtree with worker concurrently with the right one, and then returns the
+// worker.
+func (n *NamespacedMerkleTree) computeChildrenParallel(h, worker Hasher, start, split, end int) (left, right []byte, err error) {
+	var leftErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		left, leftErr = n.computeSubtreeRoot(worker, start, split)
+		n.workers <- worker
+	}()
+	right, err = n.computeSubtreeRoot(h, split, end)
+	wg.Wait()
+	if leftErr != nil { // this should never happen since leaves are added through the Push method, during which leaves formats are validated and their namespace IDs are checked to be sequential.
+		return nil, nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start, split, leftErr)
+	}
+	if err != nil { // this should never happen since leaves are added through the Push method, during which leaves formats are validated and their namespace IDs are checked to be sequential.
+		return nil, nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", split, end, err)
+	}
+	return left, right, nil
+}
+
+// visitNode calls the node visitor, serializing the calls when hashing in
+// parallel.
+func (n *NamespacedMerkleTree) visitNode(hash []byte, children ...[]byte) {
+	if n.workers != nil {
+		n.visitMtx.Lock()
+		defer n.visitMtx.Unlock()
+	}
+	n.visit(hash, children...)
+}
+
+// isCurrentNode reports whether the range [start, end), which must be that of
+// a node of the tree or of a tree of an older size, e.g., [4, 7) in a tree of
+// 8 leaves, see ProveRangeAt, is the range of a node of the current tree. The
+// nodes of a tree whose width is not a power of two are on its right spine:
+// those of an older tree are not dropped when their leaves change, see
+// dropPathNodes, hence they are never stored.
+func (n *NamespacedMerkleTree) isCurrentNode(start, end int) bool {
+	return end == n.Size() || bits.OnesCount(uint(end-start)) == 1
+}
+
+// putNode writes the node covering the leaves in the range [start, end)
+// through the node store.
+func (n *NamespacedMerkleTree) putNode(start, end int, hash []byte) error {
+	if err := n.store.Put(LeafRange{Start: start, End: end}, hash); err != nil {
+		return fmt.Errorf("failed to store node [%d, %d): %w", start, end, err)
+	}
+	return nil
+}
+
+// putLeafHashes writes the hashes of the leaves in the range [start, end) to
+// the node store if it holds the leaves, see storeLeaves. A store that may
+// hold the nodes of another tree is reset first.
+func (n *NamespacedMerkleTree) putLeafHashes(start, end int) error {
+	if !n.storeLeaves {
+		return nil
+	}
+	if !n.storeTrusted {
+		if err := n.dropNodes(); err != nil {
+			return err
+		}
+	}
+	n.storeDirty = true
+	for i := start; i < end; i++ {
+		if err := n.putNode(i, i+1, n.leafHashes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropNodes removes all the nodes from the node store. It must be called
+// whenever the leaves of the tree change.
+func (n *NamespacedMerkleTree) dropNodes() error {
+	if !n.storeDirty {
+		return nil
+	}
+	if err := n.store.Reset(); err != nil {
+		return err
+	}
+	n.storeDirty = false
+	n.storedEnd = 0
+	n.storeTrusted = true
+	return nil
+}
+
+// dropSpineNodes removes from the node store the nodes on the right spine of
+// the tree of the given size, i.e., the nodes covering the leaves in [s, size)
+// that are not complete subtrees. It must be called after leaves are appended
+// to the tree of the given size: the dropped nodes are not part of the larger
+// tree anymore, while the complete subtrees are kept, since they only cover
+// leaves that did not change. As such, the next root computation only
+// rehashes the new right spine.
+func (n *NamespacedMerkleTree) dropSpineNodes(size int) error {
+	if !n.storeTrusted {
+		return n.dropNodes()
+	}
+	if !n.storeDirty {
+		return nil
+	}
+	for start := 0; start < size && bits.OnesCount(uint(size-start)) != 1; start += getSplitPoint(size - start) {
+		if err := n.store.Delete(LeafRange{Start: start, End: size}); err != nil {
+			return fmt.Errorf("failed to delete node [%d, %d): %w", start, size, err)
+		}
+	}
+	return nil
+}
+
+// dropPathNodes removes from the node store the inner nodes on the path from
+// the leaf at index to the root. It must be called whenever that leaf changes,
+// whose hash is then written again, see putLeafHashes.
+func (n *NamespacedMerkleTree) dropPathNodes(index int) error {
+	if !n.storeTrusted {
+		return n.dropNodes()
+	}
+	if !n.storeDirty {
+		return nil
+	}
+	start, end := 0, n.Size()
+	for end-start > 1 {
+		if err := n.store.Delete(LeafRange{Start: start, End: end}); err != nil {
+			return fmt.Errorf("failed to delete node [%d, %d): %w", start, end, err)
+		}
+		if k := getSplitPoint(end - start); index < start+k {
+			end = start + k
+		} else {
+			start += k
+		}
+	}
+	return nil
+}
+
 // getSplitPoint returns the largest power of 2 less than the length.
 // Essentially, it returns the size of the left subtree in a full Merkle tree
 // with a total number of leaves equal to length.
@@ -636,33 +1428,6 @@ func getSplitPoint(length int) int {
 	return k
 }
 
-func (n *NamespacedMerkleTree) updateNamespaceRanges() {
-	if n.Size() > 0 {
-		lastIndex := n.Size() - 1
-		lastPushed := n.leaves[lastIndex]
-		var lastNsStr string
-		if n.reuseBuffers {
-			// this should be safe to do in all cases, because leaves always stay in a tree
-			// but to be on the safe side using only when optimizing for performance
-			lastNsStr = unsafeBytesToString(lastPushed[:n.treeHasher.NamespaceSize()])
-		} else {
-			lastNsStr = string(lastPushed[:n.treeHasher.NamespaceSize()])
-		}
-		lastRange, found := n.namespaceRanges[lastNsStr]
-		if !found {
-			n.namespaceRanges[lastNsStr] = LeafRange{
-				Start: lastIndex,
-				End:   lastIndex + 1,
-			}
-		} else {
-			n.namespaceRanges[lastNsStr] = LeafRange{
-				Start: lastRange.Start,
-				End:   lastRange.End + 1,
-			}
-		}
-	}
-}
-
 // validateAndExtractNamespace verifies whether ndata is a valid namespace
 // -prefixed data, and returns its namespace ID. The first `n.NamespaceSize()`
 // bytes of namespacedData is treated as its namespace ID.
@@ -730,6 +1495,61 @@ func (n *NamespacedMerkleTree) ComputeSubtreeRoot(start, end int) ([]byte, error
 	return n.computeRoot(start, end)
 }
 
+// SubtreeRoots returns the roots of the subtrees covering the leaves in the
+// range [start, end), as decomposed by ToLeafRanges for the given subtree root
+// width. These are the subtree roots verified by
+// Proof.VerifySubtreeRootInclusion, e.g., the subtree roots of a blob, from
+// which its share commitment is computed with SubtreeRootsCommitment.
+// Note: This method is Celestia specific, see ToLeafRanges.
+func (n *NamespacedMerkleTree) SubtreeRoots(start, end, subtreeWidth int) ([][]byte, error) {
+	if err := n.validateRange(start, end); err != nil {
+		return nil, err
+	}
+	ranges, err := ToLeafRanges(start, end, subtreeWidth)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([][]byte, len(ranges))
+	for i, r := range ranges {
+		root, err := n.ComputeSubtreeRoot(r.Start, r.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", r.Start, r.End, err)
+		}
+		roots[i] = root
+	}
+	return roots, nil
+}
+
+// SubtreeRootsCommitment folds subtree roots, e.g., those returned by
+// SubtreeRoots, into a single commitment: the root of the RFC 6962 Merkle tree
+// whose leaves are the subtree roots, computed with the hash function h. This
+// is how the share commitment of a blob is computed from its subtree roots as
+// per ADR-013.
+// Note: This method is Celestia specific.
+func SubtreeRootsCommitment(h hash.Hash, subtreeRoots [][]byte) []byte {
+	sum := func(prefix byte, data ...[]byte) []byte {
+		h.Reset()
+		h.Write([]byte{prefix})
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+	var fold func(roots [][]byte) []byte
+	fold = func(roots [][]byte) []byte {
+		if len(roots) == 1 {
+			return sum(LeafPrefix, roots[0])
+		}
+		k := getSplitPoint(len(roots))
+		return sum(NodePrefix, fold(roots[:k]), fold(roots[k:]))
+	}
+	if len(subtreeRoots) == 0 {
+		h.Reset()
+		return h.Sum(nil)
+	}
+	return fold(subtreeRoots)
+}
+
 type LeafRange struct {
 	// Start and End denote the indices of a leaf in the tree.
 	// Start ranges from 0 up to the total number of leaves minus 1.
@@ -754,14 +1574,21 @@ func MaxNamespace(hash []byte, size namespace.IDSize) []byte {
 
 // Size returns the number of leaves in the tree.
 func (n *NamespacedMerkleTree) Size() int {
-	return len(n.leaves)
+	return len(n.leafHashes)
+}
+
+// hasLeafData reports whether the tree holds its leaves, and not only their
+// hashes. The leaves of a tree created from leaf hashes are nil.
+func (n *NamespacedMerkleTree) hasLeafData() bool {
+	return n.leaves != nil
 }
 
-// unsafeBytesToString converts a byte slice to a string without copying memory, using unsafe operations.
-// Use with caution as modifications to the original byte slice affect the resulting string.
-func unsafeBytesToString(b []byte) string {
-	if len(b) == 0 {
-		return ""
+// leafNamespace returns the namespace ID of the leaf at the given index. If
+// the tree does not hold the leaf itself, the namespace ID is taken from the
+// leaf hash, which is prefixed by it.
+func (n *NamespacedMerkleTree) leafNamespace(index int) namespace.ID {
+	if n.hasLeafData() {
+		return n.leaves[index][:n.NamespaceSize()]
 	}
-	return unsafe.String(&b[0], len(b))
+	return MinNamespace(n.leafHashes[index], n.NamespaceSize())
 }
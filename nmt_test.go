@@ -732,6 +732,28 @@ func BenchmarkComputeRoot(b *testing.B) {
 	}
 }
 
+// BenchmarkRoot computes the root of trees from scratch, i.e., without the
+// cached root and nodes of the previous iteration.
+func BenchmarkRoot(b *testing.B) {
+	for _, numLeaves := range []int{256, 20000} {
+		data, err := generateRandNamespacedRawData(numLeaves, 8, 512)
+		require.NoError(b, err)
+		tree := New(sha256.New())
+		for _, d := range data {
+			require.NoError(b, tree.Push(d))
+		}
+		b.Run(fmt.Sprintf("%d-leaves", numLeaves), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree.rawRoot = nil
+				require.NoError(b, tree.dropNodes())
+				_, err := tree.Root()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
 func Test_Root_RaceCondition(t *testing.T) {
 	// this is very similar to: https://github.com/HuobiRDCenter/huobi_Golang/pull/9
 	tree := New(sha256.New())
@@ -935,6 +957,54 @@ func Test_buildRangeProof_Err(t *testing.T) {
 	}
 }
 
+func TestProveRangeAt(t *testing.T) {
+	tree := consistencyTestTree(t, 13, true)
+	for treeSize := 1; treeSize <= 13; treeSize++ {
+		prefix := consistencyTestTree(t, treeSize, true)
+		root, err := prefix.Root()
+		require.NoError(t, err)
+		for start := 0; start < treeSize; start++ {
+			for end := start + 1; end <= treeSize; end++ {
+				proof, err := tree.ProveRangeAt(start, end, treeSize)
+				require.NoError(t, err)
+				want, err := prefix.ProveRange(start, end)
+				require.NoError(t, err)
+				assert.Equal(t, want, proof, "range [%d, %d) of tree size %d", start, end, treeSize)
+				if end-start == 1 {
+					leaf := prefix.leaves[start]
+					assert.True(t, proof.VerifyInclusion(sha256.New(), leaf[:1], [][]byte{leaf[1:]}, root))
+				}
+			}
+		}
+	}
+
+	for _, r := range [][3]int{{0, 1, 0}, {0, 1, 14}, {3, 5, 4}, {-1, 1, 4}, {2, 2, 4}} {
+		_, err := tree.ProveRangeAt(r[0], r[1], r[2])
+		assert.ErrorIs(t, err, ErrInvalidRange, "range [%d, %d) of tree size %d", r[0], r[1], r[2])
+	}
+}
+
+// TestProveRangeAt_SetLeaf checks that the proofs in a tree of an older size
+// do not use the nodes of that tree computed before a leaf changed, such as
+// [4, 7), which is not a node of the current tree.
+func TestProveRangeAt_SetLeaf(t *testing.T) {
+	tree := consistencyTestTree(t, 8, true)
+	_, err := tree.ProveRangeAt(0, 1, 7)
+	require.NoError(t, err)
+	require.NoError(t, tree.SetLeaf(5, append([]byte{1}, "new leaf"...)))
+
+	prefix := New(sha256.New(), NamespaceIDSize(1))
+	for _, leaf := range tree.leaves[:7] {
+		require.NoError(t, prefix.Push(leaf))
+	}
+	root, err := prefix.Root()
+	require.NoError(t, err)
+	proof, err := tree.ProveRangeAt(0, 1, 7)
+	require.NoError(t, err)
+	leaf := tree.leaves[0]
+	assert.True(t, proof.VerifyInclusion(sha256.New(), leaf[:1], [][]byte{leaf[1:]}, root))
+}
+
 // Test_ProveRange_Err tests that ProveRange returns an error when the underlying tree has an invalid state e.g., leaves are not ordered by namespace ID or a leaf hash is corrupted.
 func Test_ProveRange_Err(t *testing.T) {
 	// create an NMT with 8 sequentially namespaced leaves, numbered from 1 to 8.
@@ -1347,6 +1417,70 @@ func TestProveNamespace_MaxNamespace(t *testing.T) {
 	}
 }
 
+func TestProveNamespace_ShortAbsenceProofs(t *testing.T) {
+	// the example of TestVerifyNamespace_ShortAbsenceProof_Valid
+	tree := New(sha256.New(), NamespaceIDSize(1), ShortAbsenceProofs(true))
+	for i, nID := range []byte{1, 2, 3, 4, 6, 7, 8, 9} {
+		require.NoError(t, tree.Push(append([]byte{nID}, fmt.Sprintf("leaf_%d", i)...)))
+	}
+	root, err := tree.Root()
+	require.NoError(t, err)
+	proof, err := tree.ProveNamespace(namespace.ID{5})
+	require.NoError(t, err)
+	node0to4, err := tree.computeRoot(0, 4)
+	require.NoError(t, err)
+	node4to8, err := tree.computeRoot(4, 8)
+	require.NoError(t, err)
+	assert.Equal(t, NewAbsenceProof(1, 2, [][]byte{node0to4}, node4to8, true), proof)
+	assert.True(t, proof.VerifyNamespace(sha256.New(), namespace.ID{5}, nil, root))
+
+	shorter := 0
+	for _, ignoreMax := range []bool{true, false} {
+		for size := 2; size <= 20; size++ {
+			// leaves with every other even namespace ID, the last ones with
+			// the maximum namespace ID
+			nIDs := make([]byte, size)
+			for i := range nIDs {
+				nIDs[i] = byte(2 * (i / 2))
+				if i >= size-size/4 {
+					nIDs[i] = math.MaxUint8
+				}
+			}
+			tree := exampleNMT(1, ignoreMax, nIDs...)
+			short := New(sha256.New(), NamespaceIDSize(1), IgnoreMaxNamespace(ignoreMax), ShortAbsenceProofs(true))
+			for _, leaf := range tree.leaves {
+				require.NoError(t, short.Push(leaf))
+			}
+			root, err := short.Root()
+			require.NoError(t, err)
+			for nID := 1; nID < math.MaxUint8; nID += 2 {
+				want, err := tree.ProveNamespace(namespace.ID{byte(nID)})
+				require.NoError(t, err)
+				got, err := short.ProveNamespace(namespace.ID{byte(nID)})
+				require.NoError(t, err)
+				if !want.IsOfAbsence() {
+					assert.Equal(t, want, got)
+					continue
+				}
+				assert.True(t, got.IsOfAbsence())
+				assert.LessOrEqual(t, len(got.Nodes()), len(want.Nodes()))
+				if len(got.Nodes()) < len(want.Nodes()) {
+					shorter++
+				}
+				assert.NoError(t, got.VerifyNamespaceErr(sha256.New(), namespace.ID{byte(nID)}, nil, root), "size %d, namespace %d", size, nID)
+			}
+		}
+	}
+	assert.Positive(t, shorter)
+
+	// the option is kept by read-only snapshots
+	frozen, err := tree.Freeze()
+	require.NoError(t, err)
+	got, err := frozen.ProveNamespace(namespace.ID{5})
+	require.NoError(t, err)
+	assert.Equal(t, proof, got)
+}
+
 // TestEmptyRoot_NMT tests that the empty root of a tree is the same as the empty root of a hasher with the same configuration.
 func TestEmptyRoot_NMT(t *testing.T) {
 	nIDSzie := 1
@@ -1510,3 +1644,582 @@ func TestComputeSubtreeRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestSubtreeRoots(t *testing.T) {
+	nIDs := make([]byte, 16)
+	for i := range nIDs {
+		nIDs[i] = byte(i)
+	}
+	tree := exampleNMT(1, true, nIDs...)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	nth := NewNmtHasher(sha256.New(), 1, true)
+
+	for _, subtreeWidth := range []int{1, 2, 4, 8, 16} {
+		for start := 0; start < tree.Size(); start++ {
+			for end := start + 1; end <= tree.Size(); end++ {
+				subtreeRoots, err := tree.SubtreeRoots(start, end, subtreeWidth)
+				ranges, rangesErr := ToLeafRanges(start, end, subtreeWidth)
+				if rangesErr != nil {
+					// the range is not aligned as per ADR-013
+					assert.Error(t, err)
+					continue
+				}
+				require.NoError(t, err)
+				require.Len(t, subtreeRoots, len(ranges))
+				for i, r := range ranges {
+					want, err := tree.ComputeSubtreeRoot(r.Start, r.End)
+					require.NoError(t, err)
+					assert.Equal(t, want, subtreeRoots[i])
+				}
+
+				proof, err := tree.ProveRange(start, end)
+				require.NoError(t, err)
+				ok, err := proof.VerifySubtreeRootInclusion(nth, subtreeRoots, subtreeWidth, root)
+				require.NoError(t, err)
+				assert.True(t, ok, "range [%d, %d), width %d", start, end, subtreeWidth)
+			}
+		}
+	}
+}
+
+func TestSubtreeRoots_Errors(t *testing.T) {
+	tree := exampleNMT(1, true, 0, 1, 2, 3)
+	tests := []struct {
+		name                     string
+		start, end, subtreeWidth int
+	}{
+		{"empty range", 1, 1, 2},
+		{"negative start", -1, 2, 2},
+		{"out of range", 2, 5, 2},
+		{"zero width", 0, 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tree.SubtreeRoots(tt.start, tt.end, tt.subtreeWidth)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSubtreeRootsCommitment(t *testing.T) {
+	leafHash := func(data []byte) []byte {
+		h := sha256.Sum256(append([]byte{LeafPrefix}, data...))
+		return h[:]
+	}
+	innerHash := func(left, right []byte) []byte {
+		h := sha256.Sum256(append(append([]byte{NodePrefix}, left...), right...))
+		return h[:]
+	}
+	roots := [][]byte{[]byte("root_0"), []byte("root_1"), []byte("root_2")}
+	empty := sha256.Sum256(nil)
+
+	tests := []struct {
+		name  string
+		roots [][]byte
+		want  []byte
+	}{
+		{"no roots", nil, empty[:]},
+		{"single root", roots[:1], leafHash(roots[0])},
+		{"two roots", roots[:2], innerHash(leafHash(roots[0]), leafHash(roots[1]))},
+		{"three roots", roots, innerHash(innerHash(leafHash(roots[0]), leafHash(roots[1])), leafHash(roots[2]))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SubtreeRootsCommitment(sha256.New(), tt.roots))
+		})
+	}
+}
+
+// TestNodesCache checks that the nodes are stored by Root, dropped whenever
+// the leaves change, and that proofs served from the store verify.
+func TestNodesCache(t *testing.T) {
+	const nidSize = 1
+	for size := 1; size <= 20; size++ {
+		t.Run(fmt.Sprintf("size=%d", size), func(t *testing.T) {
+			nIDs := make([]byte, size)
+			for i := range nIDs {
+				nIDs[i] = byte(i / 2)
+			}
+			store := NewMemoryNodeStore()
+			tree := exampleNMT(nidSize, true, nIDs...)
+			tree.store = store
+			assert.Zero(t, store.Len())
+
+			root, err := tree.Root()
+			require.NoError(t, err)
+			// a tree with n leaves has n-1 inner nodes, all of which are
+			// stored, while the leaf hashes are not
+			assert.Equal(t, size-1, store.Len())
+			for _, r := range nodeRanges(0, size) {
+				if r.End-r.Start == 1 {
+					_, err := store.Get(r)
+					assert.ErrorIs(t, err, ErrNodeNotFound)
+					continue
+				}
+				hash, err := store.Get(r)
+				require.NoError(t, err)
+				// compute the node in a tree with an empty store
+				want, err := exampleNMT(nidSize, true, nIDs...).computeRoot(r.Start, r.End)
+				require.NoError(t, err)
+				assert.Equal(t, want, hash)
+			}
+
+			for start := 0; start < size; start++ {
+				for end := start + 1; end <= size; end++ {
+					proof, err := tree.ProveRange(start, end)
+					require.NoError(t, err)
+					got, err := proof.computeRoot(NewNmtHasher(sha256.New(), nidSize, true), tree.leafHashes[start:end])
+					require.NoError(t, err)
+					assert.Equal(t, root, got, "range [%d, %d)", start, end)
+				}
+			}
+
+			require.NoError(t, tree.Push(namespace.PrefixedData(append(namespace.ID{byte(size)}, []byte("last")...))))
+			// only the nodes that are also nodes of the larger tree are kept
+			newRanges := make(map[LeafRange]bool)
+			for _, r := range nodeRanges(0, size+1) {
+				newRanges[r] = true
+			}
+			kept := 0
+			for _, r := range nodeRanges(0, size) {
+				if newRanges[r] && r.End-r.Start > 1 {
+					kept++
+					_, err := store.Get(r)
+					assert.NoError(t, err)
+				} else {
+					_, err := store.Get(r)
+					assert.ErrorIs(t, err, ErrNodeNotFound)
+				}
+			}
+			assert.Equal(t, kept, store.Len())
+			_, err = tree.Root()
+			require.NoError(t, err)
+			assert.Equal(t, size, store.Len())
+
+			tree.Reset()
+			assert.Zero(t, store.Len())
+		})
+	}
+}
+
+// TestNodesCache_VisitOnce checks that the proofs of a tree whose root is
+// computed are served from the store, without visiting any node again.
+func TestNodesCache_VisitOnce(t *testing.T) {
+	const nidSize = 2
+	data, err := generateRandNamespacedRawData(128, nidSize, 8)
+	require.NoError(t, err)
+
+	visited := 0
+	tree := New(sha256.New(), NamespaceIDSize(nidSize), NodeVisitor(func([]byte, ...[]byte) {
+		visited++
+	}))
+	for _, d := range data {
+		require.NoError(t, tree.Push(d))
+	}
+	_, err = tree.Root()
+	require.NoError(t, err)
+	// the 128 leaves and the 127 inner nodes
+	assert.Equal(t, 255, visited)
+
+	visited = 0
+	for _, d := range data {
+		_, err := tree.ProveNamespace(d[:nidSize])
+		require.NoError(t, err)
+	}
+	assert.Zero(t, visited)
+}
+
+func TestIncrementalRoot(t *testing.T) {
+	const nidSize = 2
+	data, err := generateRandNamespacedRawData(130, nidSize, 8)
+	require.NoError(t, err)
+
+	hashed := 0
+	tree := New(sha256.New(), NamespaceIDSize(nidSize), NodeVisitor(func(_ []byte, children ...[]byte) {
+		if len(children) == 2 {
+			hashed++
+		}
+	}))
+	for size := 1; size <= len(data); size++ {
+		require.NoError(t, tree.Push(data[size-1]))
+		hashed = 0
+		got, err := tree.Root()
+		require.NoError(t, err)
+
+		fresh := New(sha256.New(), NamespaceIDSize(nidSize))
+		for _, d := range data[:size] {
+			require.NoError(t, fresh.Push(d))
+		}
+		want, err := fresh.Root()
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "size %d", size)
+
+		// only the inner nodes on the path from the root to the new leaf are
+		// hashed
+		assert.Equal(t, len(pathRanges(size-1, size))-1, hashed, "size %d", size)
+	}
+}
+
+func TestSetLeaf(t *testing.T) {
+	const nidSize = 1
+	leafData := func(nid byte, i int) namespace.PrefixedData {
+		return append(namespace.PrefixedData{nid}, []byte(fmt.Sprintf("leaf_%d", i))...)
+	}
+	for size := 1; size <= 9; size++ {
+		nIDs := make([]byte, size)
+		for i := range nIDs {
+			nIDs[i] = byte(2 * (i / 2))
+		}
+		for index := 0; index < size; index++ {
+			// all the namespaces fitting between the neighbours of the leaf
+			lo, hi := byte(0), nIDs[size-1]+2
+			if index > 0 {
+				lo = nIDs[index-1]
+			}
+			if index < size-1 {
+				hi = nIDs[index+1]
+			}
+			for nid := lo; nid <= hi; nid++ {
+				t.Run(fmt.Sprintf("size=%d/index=%d/nid=%d", size, index, nid), func(t *testing.T) {
+					hashed := 0
+					tree := New(sha256.New(), NamespaceIDSize(nidSize), NodeVisitor(func(_ []byte, children ...[]byte) {
+						if len(children) == 2 {
+							hashed++
+						}
+					}))
+					for i, id := range nIDs {
+						require.NoError(t, tree.Push(leafData(id, i)))
+					}
+					// the root of the tree, which is never proven before the
+					// leaf is replaced, is computed once
+					_, err := tree.Root()
+					require.NoError(t, err)
+
+					require.NoError(t, tree.SetLeaf(index, leafData(nid, index)))
+					hashed = 0
+					got, err := tree.Root()
+					require.NoError(t, err)
+					// only the inner nodes on the path from the root to the leaf
+					// are hashed again
+					assert.Equal(t, len(pathRanges(index, size))-1, hashed)
+
+					wantNIDs := append([]byte(nil), nIDs...)
+					wantNIDs[index] = nid
+					want := exampleNMT(nidSize, true, wantNIDs...)
+					wantRoot, err := want.Root()
+					require.NoError(t, err)
+					assert.Equal(t, wantRoot, got)
+					assert.Equal(t, want.namespaces, tree.namespaces)
+					assert.Equal(t, want.minNID, tree.minNID)
+					assert.Equal(t, want.maxNID, tree.maxNID)
+					for id := byte(0); id <= nIDs[size-1]+2; id++ {
+						wantProof, err := want.ProveNamespace(namespace.ID{id})
+						require.NoError(t, err)
+						gotProof, err := tree.ProveNamespace(namespace.ID{id})
+						require.NoError(t, err)
+						assert.Equal(t, wantProof, gotProof)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestSetLeaf_Errors(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3)
+	root, err := tree.Root()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		index   int
+		data    namespace.PrefixedData
+		wantErr error
+	}{
+		{"negative index", -1, namespace.PrefixedData{1}, ErrInvalidRange},
+		{"index out of range", 3, namespace.PrefixedData{3}, ErrInvalidRange},
+		{"leaf too short", 1, namespace.PrefixedData{}, ErrInvalidLeafLen},
+		{"smaller than previous", 1, namespace.PrefixedData{0}, ErrInvalidPushOrder},
+		{"larger than next", 1, namespace.PrefixedData{4}, ErrInvalidPushOrder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tree.SetLeaf(tt.index, tt.data)
+			assert.ErrorIs(t, err, tt.wantErr)
+			// the tree is left untouched
+			got, err := tree.Root()
+			require.NoError(t, err)
+			assert.Equal(t, root, got)
+		})
+	}
+}
+
+func TestNewFromLeafHashes(t *testing.T) {
+	const nidSize = 2
+	for _, nIDs := range [][]byte{{}, {1}, {0, 1, 1, 3, 4, 4, 4}, {1, 1, 2, 2, 3, 3, 3, 3, 5}} {
+		t.Run(fmt.Sprintf("leaves=%v", nIDs), func(t *testing.T) {
+			want := exampleNMT(nidSize, true, nIDs...)
+			tree, err := NewFromLeafHashes(NewNmtHasher(sha256.New(), nidSize, true), want.leafHashes)
+			require.NoError(t, err)
+			assert.Equal(t, want.Size(), tree.Size())
+
+			wantRoot, err := want.Root()
+			require.NoError(t, err)
+			gotRoot, err := tree.Root()
+			require.NoError(t, err)
+			assert.Equal(t, wantRoot, gotRoot)
+
+			for start := 0; start < len(nIDs); start++ {
+				for end := start + 1; end <= len(nIDs); end++ {
+					wantProof, err := want.ProveRange(start, end)
+					require.NoError(t, err)
+					gotProof, err := tree.ProveRange(start, end)
+					require.NoError(t, err)
+					assert.Equal(t, wantProof, gotProof)
+				}
+			}
+			for id := byte(0); id <= 6; id++ {
+				nID := namespace.ID{id, id}
+				wantProof, err := want.ProveNamespace(nID)
+				require.NoError(t, err)
+				gotProof, err := tree.ProveNamespace(nID)
+				require.NoError(t, err)
+				assert.Equal(t, wantProof, gotProof)
+
+				assert.Nil(t, tree.Get(nID))
+				_, err = tree.GetErr(nID)
+				assert.ErrorIs(t, err, ErrLeafDataUnavailable)
+				_, _, err = tree.GetWithProof(nID)
+				assert.ErrorIs(t, err, ErrLeafDataUnavailable)
+				wantLeaves, err := want.GetErr(nID)
+				require.NoError(t, err)
+				assert.Equal(t, want.Get(nID), wantLeaves)
+			}
+			assert.ErrorIs(t, tree.Push(namespace.PrefixedData{6, 6}), ErrLeafDataUnavailable)
+
+			// a reset tree holds the leaves pushed to it
+			tree.Reset()
+			leaf := namespace.PrefixedData{6, 6, 'l', 'e', 'a', 'f'}
+			require.NoError(t, tree.Push(leaf))
+			got, err := tree.GetErr(namespace.ID{6, 6})
+			require.NoError(t, err)
+			assert.Equal(t, [][]byte{leaf}, got)
+		})
+	}
+}
+
+func TestNewFromLeafHashes_Errors(t *testing.T) {
+	const nidSize = 2
+	hasher := NewNmtHasher(sha256.New(), nidSize, true)
+	hashes := exampleNMT(nidSize, true, 1, 2, 3).leafHashes
+
+	tests := []struct {
+		name    string
+		hashes  [][]byte
+		wantErr error
+	}{
+		{"short leaf hash", [][]byte{hashes[0], hashes[1][:10]}, ErrInvalidNodeLen},
+		{"invalid namespace range", [][]byte{append([]byte{2, 2, 1, 1}, hashes[0][4:]...)}, ErrInvalidNodeNamespaceOrder},
+		{"unordered leaf hashes", [][]byte{hashes[0], hashes[2], hashes[1]}, ErrInvalidPushOrder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFromLeafHashes(hasher, tt.hashes)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestProveNamespaceRange(t *testing.T) {
+	const nidSize = 1
+	nIDs := []byte{1, 1, 2, 4, 4, 6, 8, 8, 255}
+	for _, ignoreMaxNamespace := range []bool{true, false} {
+		tree := exampleNMT(nidSize, ignoreMaxNamespace, nIDs...)
+		root, err := tree.Root()
+		require.NoError(t, err)
+		candidates := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 255}
+		for _, lo := range candidates {
+			for _, hi := range candidates {
+				if hi < lo {
+					continue
+				}
+				name := fmt.Sprintf("ignoreMaxNamespace=%v/[%d, %d]", ignoreMaxNamespace, lo, hi)
+				proof, err := tree.ProveNamespaceRange(namespace.ID{lo}, namespace.ID{hi})
+				require.NoError(t, err, name)
+
+				var leaves [][]byte
+				if !proof.IsEmptyProof() && !proof.IsOfAbsence() {
+					for i, nID := range nIDs {
+						if nID >= lo && nID <= hi {
+							leaves = append(leaves, tree.leaves[i])
+						}
+					}
+					assert.Equal(t, len(leaves), proof.End()-proof.Start(), name)
+				}
+				assert.True(t, proof.VerifyNamespaceRange(sha256.New(), namespace.ID{lo}, namespace.ID{hi}, leaves, root), name)
+
+				// an interval of a single namespace is proven like the namespace
+				if lo == hi {
+					want, err := tree.ProveNamespace(namespace.ID{lo})
+					require.NoError(t, err)
+					assert.Equal(t, want, proof, name)
+				}
+			}
+		}
+	}
+}
+
+func TestProveNamespaceRange_Errors(t *testing.T) {
+	tree := exampleNMT(2, true, 1, 2, 3)
+	_, err := tree.ProveNamespaceRange(namespace.ID{2, 2}, namespace.ID{1, 1})
+	assert.Error(t, err)
+	_, err = tree.ProveNamespaceRange(namespace.ID{1}, namespace.ID{2, 2})
+	assert.Error(t, err)
+
+	// an empty tree does not contain any namespace
+	proof, err := exampleNMT(2, true).ProveNamespaceRange(namespace.ID{1, 1}, namespace.ID{2, 2})
+	require.NoError(t, err)
+	assert.True(t, proof.IsEmptyProof())
+}
+
+// pathRanges returns the leaf ranges of the nodes on the path from the root of
+// a tree of the given size to the leaf at index.
+func pathRanges(index, size int) []LeafRange {
+	var ranges []LeafRange
+	start, end := 0, size
+	for {
+		ranges = append(ranges, LeafRange{Start: start, End: end})
+		if end-start == 1 {
+			return ranges
+		}
+		if k := getSplitPoint(end - start); index < start+k {
+			end = start + k
+		} else {
+			start += k
+		}
+	}
+}
+
+// nodeRanges returns the leaf ranges of all the nodes of the tree covering the
+// leaves in the range [start, end), in post-order.
+func nodeRanges(start, end int) []LeafRange {
+	if end-start == 1 {
+		return []LeafRange{{Start: start, End: end}}
+	}
+	k := getSplitPoint(end - start)
+	ranges := append(nodeRanges(start, start+k), nodeRanges(start+k, end)...)
+	return append(ranges, LeafRange{Start: start, End: end})
+}
+
+func BenchmarkProveNamespace(b *testing.B) {
+	tests := []struct {
+		name      string
+		numLeaves int
+		nidSize   int
+		dataSize  int
+	}{
+		{"128-leaves", 128, 29, 512},
+		{"256-leaves", 256, 29, 512},
+		{"512-leaves", 512, 29, 512},
+	}
+
+	for _, tt := range tests {
+		data, err := generateRandNamespacedRawData(tt.numLeaves, tt.nidSize, tt.dataSize)
+		require.NoError(b, err)
+		tree := New(sha256.New(), NamespaceIDSize(tt.nidSize))
+		for _, d := range data {
+			require.NoError(b, tree.Push(d))
+		}
+
+		// both paths return the same proofs
+		root, err := tree.Root()
+		require.NoError(b, err)
+		for _, d := range data {
+			r, _ := tree.namespaceProofRange(d[:tt.nidSize], root)
+			want, err := tree.buildRangeProof(r.Start, r.End)
+			require.NoError(b, err)
+			got, err := rehashedRangeProof(tree, r.Start, r.End)
+			require.NoError(b, err)
+			require.Equal(b, want, got)
+		}
+
+		// proves every namespace of the tree, using the cached inner nodes
+		b.Run(tt.name+"-Cached", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, d := range data {
+					_, err := tree.ProveNamespace(d[:tt.nidSize])
+					require.NoError(b, err)
+				}
+			}
+		})
+
+		// proves every namespace of the tree as before the inner nodes were
+		// cached, i.e., rehashing the whole tree for each proof
+		b.Run(tt.name+"-Recomputed", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, d := range data {
+					root, err := tree.Root()
+					require.NoError(b, err)
+					r, _ := tree.namespaceProofRange(d[:tt.nidSize], root)
+					_, err = rehashedRangeProof(tree, r.Start, r.End)
+					require.NoError(b, err)
+				}
+			}
+		})
+	}
+}
+
+// rehashedRangeProof returns the nodes in the proof of the range [proofStart,
+// proofEnd) of the tree like buildRangeProof did before the inner nodes were
+// cached: every node of the tree is hashed again from the leaf hashes.
+func rehashedRangeProof(n *NamespacedMerkleTree, proofStart, proofEnd int) ([][]byte, error) {
+	proof := [][]byte{}
+	var recurse func(start, end int, includeNode bool) ([]byte, error)
+	recurse = func(start, end int, includeNode bool) ([]byte, error) {
+		if start >= n.Size() {
+			return nil, nil
+		}
+		if end-start == 1 {
+			leafHash := n.leafHashes[start]
+			if (start < proofStart || start >= proofEnd) && includeNode {
+				proof = append(proof, leafHash)
+			}
+			return leafHash, nil
+		}
+		newIncludeNode := includeNode
+		if (end <= proofStart || start >= proofEnd) && includeNode {
+			newIncludeNode = false
+		}
+		k := getSplitPoint(end - start)
+		left, err := recurse(start, start+k, newIncludeNode)
+		if err != nil {
+			return nil, err
+		}
+		right, err := recurse(start+k, end, newIncludeNode)
+		if err != nil {
+			return nil, err
+		}
+		hash := left
+		if right != nil {
+			hash, err = n.treeHasher.HashNode(left, right)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if includeNode && !newIncludeNode {
+			proof = append(proof, hash)
+		}
+		return hash, nil
+	}
+	fullTreeSize := getSplitPoint(n.Size()) * 2
+	if fullTreeSize < 1 {
+		fullTreeSize = 1
+	}
+	if _, err := recurse(0, fullTreeSize, true); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
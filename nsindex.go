@@ -0,0 +1,141 @@
+package nmt
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// namespaceIndex is a sorted index of the namespaces of the leaves of a tree,
+// which are ordered by namespace ID: it holds the distinct namespace IDs of
+// the leaves in ascending order, together with the index of the first leaf of
+// each, i.e., the boundaries of the namespaces. It looks up the range of the
+// leaves of a namespace, the position of an absent namespace and the
+// neighbours of a namespace by binary search, in O(log n) for n namespaces.
+// The namespace IDs are copied into a single buffer, so that appending a leaf
+// does not allocate beyond the amortized growth of the index.
+type namespaceIndex struct {
+	// nIDSize is the size of the namespace IDs in bytes.
+	nIDSize int
+	// nIDs holds the distinct namespace IDs of the leaves in ascending order,
+	// concatenated.
+	nIDs []byte
+	// starts holds the index of the first leaf of each namespace of nIDs.
+	starts []int
+	// size is the number of leaves of the tree.
+	size int
+}
+
+func newNamespaceIndex(nIDSize namespace.IDSize) namespaceIndex {
+	return namespaceIndex{nIDSize: int(nIDSize)}
+}
+
+// len returns the number of distinct namespaces.
+func (idx *namespaceIndex) len() int {
+	return len(idx.starts)
+}
+
+// nID returns the i-th smallest namespace ID, which must not be modified.
+func (idx *namespaceIndex) nID(i int) namespace.ID {
+	return idx.nIDs[i*idx.nIDSize : (i+1)*idx.nIDSize : (i+1)*idx.nIDSize]
+}
+
+// leafRange returns the range of the leaves of the i-th smallest namespace.
+func (idx *namespaceIndex) leafRange(i int) LeafRange {
+	end := idx.size
+	if i+1 < idx.len() {
+		end = idx.starts[i+1]
+	}
+	return LeafRange{Start: idx.starts[i], End: end}
+}
+
+// search returns the position of the smallest namespace ID of the index that
+// is not smaller than nID, or idx.len() if there is none, and whether it is
+// equal to nID.
+func (idx *namespaceIndex) search(nID namespace.ID) (i int, found bool) {
+	lo, hi := 0, idx.len()
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch bytes.Compare(idx.nID(mid), nID) {
+		case -1:
+			lo = mid + 1
+		case 0:
+			return mid, true
+		default:
+			hi = mid
+		}
+	}
+	return lo, false
+}
+
+// lookup returns the range of the leaves of the namespace nID, and whether the
+// tree has any.
+func (idx *namespaceIndex) lookup(nID namespace.ID) (LeafRange, bool) {
+	i, found := idx.search(nID)
+	if !found {
+		return LeafRange{}, false
+	}
+	return idx.leafRange(i), true
+}
+
+// push records the namespace ID of a leaf appended to the tree.
+func (idx *namespaceIndex) push(nID namespace.ID) {
+	if last := idx.len() - 1; last < 0 || !idx.nID(last).Equal(nID) {
+		idx.nIDs = append(idx.nIDs, nID...)
+		idx.starts = append(idx.starts, idx.size)
+	}
+	idx.size++
+}
+
+// set moves the leaf at index from the namespace oldNID to the namespace nID.
+// The leaf must be at the boundary of both ranges, which holds as long as the
+// leaves are ordered by namespace ID.
+func (idx *namespaceIndex) set(index int, oldNID, nID namespace.ID) {
+	i, _ := idx.search(oldNID)
+	switch r := idx.leafRange(i); {
+	case r.End-r.Start == 1:
+		idx.remove(i)
+	case index == r.Start:
+		idx.starts[i]++
+	}
+	// the range of the leaf is either merged into the range of a neighbour,
+	// which ends or starts next to it, or becomes a namespace of its own
+	j, found := idx.search(nID)
+	switch {
+	case !found:
+		idx.insert(j, nID, index)
+	case idx.starts[j] == index+1:
+		idx.starts[j] = index
+	}
+}
+
+// insert inserts the namespace nID, whose first leaf is at start, at position
+// i of the index.
+func (idx *namespaceIndex) insert(i int, nID namespace.ID, start int) {
+	idx.nIDs = slices.Insert(idx.nIDs, i*idx.nIDSize, nID...)
+	idx.starts = slices.Insert(idx.starts, i, start)
+}
+
+// remove removes the namespace at position i of the index.
+func (idx *namespaceIndex) remove(i int) {
+	idx.nIDs = slices.Delete(idx.nIDs, i*idx.nIDSize, (i+1)*idx.nIDSize)
+	idx.starts = slices.Delete(idx.starts, i, i+1)
+}
+
+// reset empties the index, keeping its buffers for reuse.
+func (idx *namespaceIndex) reset() {
+	idx.nIDs = idx.nIDs[:0]
+	idx.starts = idx.starts[:0]
+	idx.size = 0
+}
+
+// clone returns a deep copy of the index.
+func (idx *namespaceIndex) clone() namespaceIndex {
+	return namespaceIndex{
+		nIDSize: idx.nIDSize,
+		nIDs:    bytes.Clone(idx.nIDs),
+		starts:  slices.Clone(idx.starts),
+		size:    idx.size,
+	}
+}
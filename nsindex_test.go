@@ -0,0 +1,140 @@
+package nmt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+func TestNamespaceIndex(t *testing.T) {
+	for _, nIDs := range [][]byte{
+		{},
+		{3},
+		{3, 3, 3},
+		{1, 3, 5, 7},
+		{1, 1, 3, 5, 5, 5, 7, 9, 9},
+		{0, 2, 2, 4, 6, 6, 8, 8, 8, 8, 10, 12, 14, 14, 16, 255},
+	} {
+		tree := exampleNMT(1, true, nIDs...)
+		for nID := 0; nID <= 255; nID++ {
+			id := namespace.ID{byte(nID)}
+
+			// the expected results, by a linear scan of the leaves
+			wantRange := LeafRange{}
+			var wantPrev, wantNext namespace.ID
+			for i, leafNID := range nIDs {
+				switch {
+				case leafNID < byte(nID):
+					wantPrev = namespace.ID{leafNID}
+				case leafNID == byte(nID):
+					if wantRange.End == 0 {
+						wantRange.Start = i
+					}
+					wantRange.End = i + 1
+				case wantNext == nil:
+					wantNext = namespace.ID{leafNID}
+				}
+			}
+
+			found, start, end := tree.foundInRange(id)
+			assert.Equal(t, wantRange.End != 0, found, "namespace %d of %v", nID, nIDs)
+			assert.Equal(t, wantRange, LeafRange{Start: start, End: end}, "namespace %d of %v", nID, nIDs)
+			prev, next := tree.NeighborNamespaces(id)
+			assert.Equal(t, wantPrev, prev, "namespace %d of %v", nID, nIDs)
+			assert.Equal(t, wantNext, next, "namespace %d of %v", nID, nIDs)
+			if !found && wantPrev != nil && wantNext != nil {
+				// the first leaf of the next namespace
+				index := tree.calculateAbsenceIndex(id)
+				assert.Equal(t, wantNext, tree.leafNamespace(index))
+				assert.Equal(t, wantPrev, tree.leafNamespace(index-1))
+			}
+		}
+	}
+}
+
+func TestNamespaceIndex_Reset(t *testing.T) {
+	tree := New(sha256.New(), NamespaceIDSize(1), ReuseBuffers(true))
+	leaf := []byte{1, 'l', 'e', 'a', 'f'}
+	require.NoError(t, tree.Push(leaf))
+	tree.Reset()
+	// the index keeps neither the namespaces of the leaves pushed before the
+	// reset, nor the memory of those leaves, which is reused
+	leaf[0] = 2
+	require.NoError(t, tree.Push(leaf))
+	found, _, _ := tree.foundInRange(namespace.ID{1})
+	assert.False(t, found)
+	found, start, end := tree.foundInRange(namespace.ID{2})
+	assert.True(t, found)
+	assert.Equal(t, LeafRange{Start: 0, End: 1}, LeafRange{Start: start, End: end})
+}
+
+// benchmarkLeaves returns numLeaves leaves with 8-byte namespace IDs, in
+// ascending order, and about leavesPerNamespace leaves per namespace. The
+// namespace IDs are even, so that the odd ones are absent from the tree.
+func benchmarkLeaves(numLeaves, leavesPerNamespace int) [][]byte {
+	leaves := make([][]byte, numLeaves)
+	for i := range leaves {
+		nID := uint64(2 * (i / leavesPerNamespace))
+		leaves[i] = append(binary.BigEndian.AppendUint64(nil, nID), "leaf data"...)
+	}
+	return leaves
+}
+
+func BenchmarkPush_10kLeaves(b *testing.B) {
+	for _, leavesPerNamespace := range []int{1, 4, 64} {
+		leaves := benchmarkLeaves(10_000, leavesPerNamespace)
+		b.Run(fmt.Sprintf("%d-leaves-per-namespace", leavesPerNamespace), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree := New(sha256.New(), NamespaceIDSize(8), InitialCapacity(len(leaves)))
+				for _, leaf := range leaves {
+					if err := tree.Push(leaf); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNamespaceLookup_10kLeaves(b *testing.B) {
+	leaves := benchmarkLeaves(10_000, 4)
+	tree := New(sha256.New(), NamespaceIDSize(8))
+	for _, leaf := range leaves {
+		require.NoError(b, tree.Push(leaf))
+	}
+	nIDs := make([]namespace.ID, 0, len(leaves)/4)
+	absentNIDs := make([]namespace.ID, 0, len(leaves)/4)
+	for i := 0; i+4 < len(leaves); i += 4 {
+		nID := namespace.ID(leaves[i][:8])
+		absentNID := append(namespace.ID(nil), nID...)
+		absentNID[7]++
+		nIDs = append(nIDs, nID)
+		absentNIDs = append(absentNIDs, absentNID)
+	}
+
+	b.Run("present", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if found, _, _ := tree.foundInRange(nIDs[i%len(nIDs)]); !found {
+				b.Fatal("namespace not found")
+			}
+		}
+	})
+	b.Run("absent", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			nID := absentNIDs[i%len(absentNIDs)]
+			if found, _, _ := tree.foundInRange(nID); found {
+				b.Fatal("namespace found")
+			}
+			tree.calculateAbsenceIndex(nID)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package nmt
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// defaultParallelThreshold is the minimum number of leaves of a subtree for
+// its left and right subtrees to be hashed concurrently. Smaller subtrees are
+// cheaper to hash than to hand over to another goroutine.
+const defaultParallelThreshold = 256
+
+// newWorkerHashers returns a channel holding the hashers of the additional
+// goroutines used for parallel hashing, i.e., one less than the number of
+// workers, or nil if parallel hashing is disabled or unsupported.
+func newWorkerHashers(opts *Options) chan Hasher {
+	if opts.ParallelWorkers < 2 {
+		return nil
+	}
+	// the worker hashers must hash exactly like the tree hasher, which is only
+	// guaranteed for the default hasher
+	nth, ok := opts.Hasher.(*NmtHasher)
+	if !ok {
+		return nil
+	}
+	workers := make(chan Hasher, opts.ParallelWorkers-1)
+	for i := 0; i < opts.ParallelWorkers-1; i++ {
+		workers <- NewNmtHasher(opts.NewBaseHash(), nth.NamespaceSize(), nth.IsMaxNamespaceIDIgnored())
+	}
+	return workers
+}
+
+// acquireWorker returns the hasher of an idle worker if a subtree of the given
+// size should be hashed concurrently, and nil otherwise. The hasher must be
+// put back into n.workers once the worker is done.
+func (n *NamespacedMerkleTree) acquireWorker(size int) Hasher {
+	if n.workers == nil || size < n.parallelThreshold {
+		return nil
+	}
+	select {
+	case worker := <-n.workers:
+		return worker
+	default:
+		return nil
+	}
+}
+
+// hashLeaves returns the namespaced hashes of leaves. The leaves are split
+// into contiguous chunks, one for the tree hasher and one for each idle
+// worker, as long as the chunks are at least as large as the parallel
+// threshold, and the chunks are hashed concurrently. If any leaf cannot be
+// hashed, hashLeaves returns the error of the first such leaf.
+func (n *NamespacedMerkleTree) hashLeaves(leaves []namespace.PrefixedData) ([][]byte, error) {
+	hashers := []Hasher{n.treeHasher}
+	for {
+		worker := n.acquireWorker(len(leaves) / (len(hashers) + 1))
+		if worker == nil {
+			break
+		}
+		hashers = append(hashers, worker)
+	}
+
+	hashes := make([][]byte, len(leaves))
+	errs := make([]error, len(hashers))
+	chunkSize := (len(leaves) + len(hashers) - 1) / len(hashers)
+	hashChunk := func(w int) {
+		for i := w * chunkSize; i < min((w+1)*chunkSize, len(leaves)); i++ {
+			hash, err := hashers[w].HashLeaf(leaves[i])
+			if err != nil {
+				errs[w] = fmt.Errorf("failed to hash leaf %d: %w", i, err)
+				return
+			}
+			hashes[i] = hash
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := 1; w < len(hashers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hashChunk(w)
+			n.workers <- hashers[w]
+		}()
+	}
+	hashChunk(0)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
@@ -0,0 +1,257 @@
+package nmt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+func TestParallelHashing(t *testing.T) {
+	const nidSize = 8
+	data, err := generateRandNamespacedRawData(300, nidSize, 32)
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, 2, 3, 7, 8, 9, 64, 100, 255, 256, 300} {
+		for _, workers := range []int{1, 2, 3, 8} {
+			t.Run(fmt.Sprintf("size=%d,workers=%d", size, workers), func(t *testing.T) {
+				sequential := New(sha256.New(), NamespaceIDSize(nidSize))
+				parallel := New(sha256.New(), NamespaceIDSize(nidSize), ParallelHashing(workers, sha256.New))
+				// hash even the smallest subtrees concurrently
+				parallel.parallelThreshold = 2
+				for _, d := range data[:size] {
+					require.NoError(t, sequential.Push(d))
+					require.NoError(t, parallel.Push(d))
+				}
+				want, err := sequential.Root()
+				require.NoError(t, err)
+				got, err := parallel.Root()
+				require.NoError(t, err)
+				assert.Equal(t, want, got)
+
+				if size > 0 {
+					wantProof, err := sequential.ProveRange(0, (size+1)/2)
+					require.NoError(t, err)
+					gotProof, err := parallel.ProveRange(0, (size+1)/2)
+					require.NoError(t, err)
+					assert.Equal(t, wantProof, gotProof)
+				}
+			})
+		}
+	}
+}
+
+func TestParallelHashing_NodeVisitor(t *testing.T) {
+	const nidSize = 8
+	data, err := generateRandNamespacedRawData(100, nidSize, 32)
+	require.NoError(t, err)
+
+	// visited records the visited nodes; its accesses are not synchronized, so
+	// that the race detector catches concurrent visits
+	visited := make(map[string]bool)
+	var order [][]byte
+	visitor := func(hash []byte, children ...[]byte) {
+		if len(children) == 2 {
+			// children are visited before their parents
+			assert.True(t, visited[string(children[0])])
+			assert.True(t, visited[string(children[1])])
+		}
+		visited[string(hash)] = true
+		order = append(order, hash)
+	}
+	tree := New(sha256.New(), NamespaceIDSize(nidSize), NodeVisitor(visitor), ParallelHashing(4, sha256.New))
+	tree.parallelThreshold = 2
+	for _, d := range data {
+		require.NoError(t, tree.Push(d))
+	}
+	root, err := tree.Root()
+	require.NoError(t, err)
+
+	// every node is visited exactly once, and the root last
+	assert.Len(t, order, 2*len(data)-1)
+	assert.True(t, bytes.Equal(root, order[len(order)-1]))
+}
+
+func TestParallelHashing_CustomHasher(t *testing.T) {
+	h := nonBufferedHasher{NewNmtHasher(sha256.New(), 8, true)}
+	tree := New(sha256.New(), CustomHasher(h), ParallelHashing(4, sha256.New))
+	assert.Nil(t, tree.workers)
+}
+
+func TestParallelHashing_InvalidOptions(t *testing.T) {
+	shouldPanic(t, func() {
+		_ = New(sha256.New(), ParallelHashing(0, sha256.New))
+	})
+	shouldPanic(t, func() {
+		_ = New(sha256.New(), ParallelHashing(4, nil))
+	})
+}
+
+func TestPushBatch(t *testing.T) {
+	const nidSize = 8
+	data, err := generateRandNamespacedRawData(700, nidSize, 32)
+	require.NoError(t, err)
+	batch := make([]namespace.PrefixedData, len(data))
+	for i, d := range data {
+		batch[i] = d
+	}
+
+	for _, size := range []int{0, 1, 5, 100, 300, 700} {
+		for _, workers := range []int{1, 2, 8} {
+			t.Run(fmt.Sprintf("size=%d,workers=%d", size, workers), func(t *testing.T) {
+				want := New(sha256.New(), NamespaceIDSize(nidSize))
+				for _, d := range data[:size] {
+					require.NoError(t, want.Push(d))
+				}
+				wantRoot, err := want.Root()
+				require.NoError(t, err)
+
+				tree := New(sha256.New(), NamespaceIDSize(nidSize), ParallelHashing(workers, sha256.New))
+				// split even the smallest batches among the workers
+				tree.parallelThreshold = 8
+				// the first leaves are pushed one by one, and the root is
+				// computed before pushing the rest in two batches
+				for _, d := range data[:size/3] {
+					require.NoError(t, tree.Push(d))
+				}
+				_, err = tree.Root()
+				require.NoError(t, err)
+				require.NoError(t, tree.PushBatch(batch[size/3:size/2]))
+				require.NoError(t, tree.PushBatch(batch[size/2:size]))
+
+				gotRoot, err := tree.Root()
+				require.NoError(t, err)
+				assert.Equal(t, wantRoot, gotRoot)
+				assert.Equal(t, want.leafHashes, tree.leafHashes)
+				assert.Equal(t, want.namespaces, tree.namespaces)
+				assert.Equal(t, want.minNID, tree.minNID)
+				assert.Equal(t, want.maxNID, tree.maxNID)
+				if size > 0 {
+					nID := namespace.ID(data[size/2][:nidSize])
+					wantProof, err := want.ProveNamespace(nID)
+					require.NoError(t, err)
+					gotProof, err := tree.ProveNamespace(nID)
+					require.NoError(t, err)
+					assert.Equal(t, wantProof, gotProof)
+				}
+				// all the workers are idle again
+				if workers > 1 {
+					assert.Len(t, tree.workers, workers-1)
+				}
+			})
+		}
+	}
+}
+
+var errHashFailed = errors.New("hash failed")
+
+// failingHasher fails to hash the leaves holding failData.
+type failingHasher struct {
+	*NmtHasher
+	failData []byte
+}
+
+func (h failingHasher) HashLeaf(data []byte) ([]byte, error) {
+	if bytes.Contains(data, h.failData) {
+		return nil, errHashFailed
+	}
+	return h.NmtHasher.HashLeaf(data)
+}
+
+func TestPushBatch_Errors(t *testing.T) {
+	leaf := func(nID byte, data string) namespace.PrefixedData {
+		return append(namespace.PrefixedData{0, nID}, data...)
+	}
+	tests := []struct {
+		name    string
+		batch   []namespace.PrefixedData
+		wantErr error
+	}{
+		{"leaf without namespace", []namespace.PrefixedData{leaf(3, "a"), {3}}, ErrInvalidLeafLen},
+		{"leaves out of order", []namespace.PrefixedData{leaf(3, "a"), leaf(5, "b"), leaf(4, "c")}, ErrInvalidPushOrder},
+		{"leaf smaller than the last one of the tree", []namespace.PrefixedData{leaf(1, "a"), leaf(3, "b")}, ErrInvalidPushOrder},
+		{"leaf that fails to hash", []namespace.PrefixedData{leaf(3, "a"), leaf(3, "fail"), leaf(4, "b")}, errHashFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := failingHasher{NewNmtHasher(sha256.New(), 2, true), []byte("fail")}
+			tree := New(sha256.New(), NamespaceIDSize(2), CustomHasher(h))
+			require.NoError(t, tree.PushBatch([]namespace.PrefixedData{leaf(0, "a"), leaf(2, "b")}))
+			root, err := tree.Root()
+			require.NoError(t, err)
+			namespaces := tree.namespaces.clone()
+
+			assert.ErrorIs(t, tree.PushBatch(tt.batch), tt.wantErr)
+			// the tree is left unchanged
+			assert.Equal(t, 2, tree.Size())
+			assert.Equal(t, namespaces, tree.namespaces)
+			gotRoot, err := tree.Root()
+			require.NoError(t, err)
+			assert.Equal(t, root, gotRoot)
+			assert.Equal(t, namespace.ID{0, 2}, tree.maxNID)
+		})
+	}
+
+	tree := exampleNMT(1, true, 0, 1)
+	fromHashes, err := NewFromLeafHashes(tree.treeHasher, tree.leafHashes)
+	require.NoError(t, err)
+	assert.ErrorIs(t, fromHashes.PushBatch([]namespace.PrefixedData{{2}}), ErrLeafDataUnavailable)
+}
+
+func BenchmarkComputeRoot_Parallel(b *testing.B) {
+	const nidSize = 29
+	data, err := generateRandNamespacedRawData(1<<14, nidSize, 512)
+	require.NoError(b, err)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("16k-leaves-%d-workers", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			tree := New(sha256.New(), NamespaceIDSize(nidSize), InitialCapacity(len(data)), ParallelHashing(workers, sha256.New))
+			for _, d := range data {
+				require.NoError(b, tree.Push(d))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				require.NoError(b, tree.dropNodes())
+				tree.rawRoot = nil
+				_, err := tree.Root()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkPushBatch(b *testing.B) {
+	const nidSize = 29
+	data, err := generateRandNamespacedRawData(1<<14, nidSize, 512)
+	require.NoError(b, err)
+	batch := make([]namespace.PrefixedData, len(data))
+	for i, d := range data {
+		batch[i] = d
+	}
+
+	b.Run("16k-leaves-push", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tree := New(sha256.New(), NamespaceIDSize(nidSize), InitialCapacity(len(data)))
+			for _, d := range data {
+				require.NoError(b, tree.Push(d))
+			}
+		}
+	})
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("16k-leaves-batch-%d-workers", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree := New(sha256.New(), NamespaceIDSize(nidSize), InitialCapacity(len(data)), ParallelHashing(workers, sha256.New))
+				require.NoError(b, tree.PushBatch(batch))
+			}
+		})
+	}
+}
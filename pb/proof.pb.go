@@ -37,6 +37,10 @@ type Proof struct {
 	// The is_max_namespace_ignored flag influences the calculation of the
 	// namespace ID range for intermediate nodes in the tree.
 	IsMaxNamespaceIgnored bool `protobuf:"varint,5,opt,name=is_max_namespace_ignored,json=isMaxNamespaceIgnored,proto3" json:"is_max_namespace_ignored,omitempty"`
+	// tree_size is the number of leaves of the tree the proof was generated
+	// from. It is 0 if unknown, in which case the shape of the tree is
+	// estimated from the proof range during verification.
+	TreeSize int64 `protobuf:"varint,6,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
 }
 
 func (m *Proof) Reset()         { *m = Proof{} }
@@ -107,29 +111,239 @@ func (m *Proof) GetIsMaxNamespaceIgnored() bool {
 	return false
 }
 
+func (m *Proof) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+type MultiProof struct {
+	// Indices of the proven leaves, in ascending order.
+	Indices []int64 `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	// Nodes hold the tree nodes necessary for the Merkle proof of the leaves,
+	// in the order of an in-order traversal of the tree.
+	Nodes [][]byte `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// The is_max_namespace_ignored flag influences the calculation of the
+	// namespace ID range for intermediate nodes in the tree.
+	IsMaxNamespaceIgnored bool `protobuf:"varint,3,opt,name=is_max_namespace_ignored,json=isMaxNamespaceIgnored,proto3" json:"is_max_namespace_ignored,omitempty"`
+}
+
+func (m *MultiProof) Reset()         { *m = MultiProof{} }
+func (m *MultiProof) String() string { return proto.CompactTextString(m) }
+func (*MultiProof) ProtoMessage()    {}
+func (*MultiProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2e2daa763cd7daf3, []int{1}
+}
+func (m *MultiProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MultiProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MultiProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MultiProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiProof.Merge(m, src)
+}
+func (m *MultiProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *MultiProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiProof proto.InternalMessageInfo
+
+func (m *MultiProof) GetIndices() []int64 {
+	if m != nil {
+		return m.Indices
+	}
+	return nil
+}
+
+func (m *MultiProof) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *MultiProof) GetIsMaxNamespaceIgnored() bool {
+	if m != nil {
+		return m.IsMaxNamespaceIgnored
+	}
+	return false
+}
+
+type CompactProof struct {
+	// Start index of the proven leaves.
+	Start uint64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	// End index (non-inclusive) of the proven leaves.
+	End uint64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// tree_size is the number of leaves of the tree the proof was generated
+	// from, or 0 if unknown.
+	TreeSize uint64 `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	// The is_max_namespace_ignored flag influences the calculation of the
+	// namespace ID range for intermediate nodes in the tree.
+	IsMaxNamespaceIgnored bool `protobuf:"varint,4,opt,name=is_max_namespace_ignored,json=isMaxNamespaceIgnored,proto3" json:"is_max_namespace_ignored,omitempty"`
+	// has_leaf_hash is set for absence proofs, whose leaf hash is encoded
+	// after the nodes.
+	HasLeafHash bool `protobuf:"varint,5,opt,name=has_leaf_hash,json=hasLeafHash,proto3" json:"has_leaf_hash,omitempty"`
+	// namespace_size is the size of the namespace IDs of the nodes.
+	NamespaceSize uint32 `protobuf:"varint,6,opt,name=namespace_size,json=namespaceSize,proto3" json:"namespace_size,omitempty"`
+	// digest_size is the size of the digests of the nodes.
+	DigestSize uint32 `protobuf:"varint,7,opt,name=digest_size,json=digestSize,proto3" json:"digest_size,omitempty"`
+	// namespaces holds the minimum and maximum namespace IDs of the nodes,
+	// followed by those of the leaf hash. Every namespace ID is encoded as a
+	// varint of 2*n+r, followed by the namespace_size-n bytes following its
+	// first n bytes, which are shared with a reference namespace ID: the
+	// previous namespace ID if r is 0, or the maximum namespace ID if r is 1.
+	// The reference of the first namespace ID is the minimum namespace ID.
+	Namespaces []byte `protobuf:"bytes,8,opt,name=namespaces,proto3" json:"namespaces,omitempty"`
+	// digests holds the digests of the nodes, followed by that of the leaf
+	// hash.
+	Digests []byte `protobuf:"bytes,9,opt,name=digests,proto3" json:"digests,omitempty"`
+}
+
+func (m *CompactProof) Reset()         { *m = CompactProof{} }
+func (m *CompactProof) String() string { return proto.CompactTextString(m) }
+func (*CompactProof) ProtoMessage()    {}
+func (*CompactProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2e2daa763cd7daf3, []int{2}
+}
+func (m *CompactProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CompactProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CompactProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CompactProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompactProof.Merge(m, src)
+}
+func (m *CompactProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *CompactProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompactProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompactProof proto.InternalMessageInfo
+
+func (m *CompactProof) GetStart() uint64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *CompactProof) GetEnd() uint64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *CompactProof) GetTreeSize() uint64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *CompactProof) GetIsMaxNamespaceIgnored() bool {
+	if m != nil {
+		return m.IsMaxNamespaceIgnored
+	}
+	return false
+}
+
+func (m *CompactProof) GetHasLeafHash() bool {
+	if m != nil {
+		return m.HasLeafHash
+	}
+	return false
+}
+
+func (m *CompactProof) GetNamespaceSize() uint32 {
+	if m != nil {
+		return m.NamespaceSize
+	}
+	return 0
+}
+
+func (m *CompactProof) GetDigestSize() uint32 {
+	if m != nil {
+		return m.DigestSize
+	}
+	return 0
+}
+
+func (m *CompactProof) GetNamespaces() []byte {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+func (m *CompactProof) GetDigests() []byte {
+	if m != nil {
+		return m.Digests
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Proof)(nil), "proof.pb.Proof")
+	proto.RegisterType((*MultiProof)(nil), "proof.pb.MultiProof")
+	proto.RegisterType((*CompactProof)(nil), "proof.pb.CompactProof")
 }
 
 func init() { proto.RegisterFile("pb/proof.proto", fileDescriptor_2e2daa763cd7daf3) }
 
 var fileDescriptor_2e2daa763cd7daf3 = []byte{
-	// 236 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x34, 0xcf, 0x31, 0x4b, 0xc4, 0x30,
-	0x14, 0x07, 0xf0, 0xc6, 0x5a, 0xa9, 0xe1, 0x10, 0x09, 0x0a, 0x01, 0x31, 0x14, 0xa7, 0x4e, 0xd7,
-	0xc1, 0xe1, 0x76, 0x27, 0x1d, 0x14, 0xe9, 0xe8, 0x52, 0x5e, 0xda, 0x5c, 0x1b, 0xb8, 0x26, 0x21,
-	0x2f, 0xc2, 0x7d, 0x0c, 0x77, 0xbf, 0x90, 0xe3, 0x8d, 0x8e, 0xd2, 0x7e, 0x11, 0xc9, 0x55, 0xb7,
-	0xf7, 0xfb, 0xff, 0xdf, 0xf2, 0xa7, 0x17, 0x4e, 0x56, 0xce, 0x5b, 0xbb, 0x5d, 0x3b, 0x6f, 0x83,
-	0x65, 0xf9, 0x1f, 0xe4, 0xdd, 0x27, 0xa1, 0xd9, 0x6b, 0x04, 0xbb, 0xa2, 0x19, 0x06, 0xf0, 0x81,
-	0x93, 0x82, 0x94, 0x69, 0xbd, 0x80, 0x5d, 0xd2, 0x54, 0x99, 0x8e, 0x9f, 0x1c, 0xb3, 0x78, 0xc6,
-	0x3f, 0x63, 0x3b, 0x85, 0x3c, 0x2d, 0xd2, 0x72, 0x55, 0x2f, 0x60, 0x37, 0xf4, 0x7c, 0xa7, 0x60,
-	0xdb, 0x0c, 0x80, 0x03, 0x3f, 0x2d, 0x48, 0xb9, 0xaa, 0xf3, 0x18, 0x3c, 0x02, 0x0e, 0x6c, 0x43,
-	0xb9, 0xc6, 0x66, 0x84, 0x7d, 0x63, 0x60, 0x54, 0xe8, 0xa0, 0x55, 0x8d, 0xee, 0x8d, 0xf5, 0xaa,
-	0xe3, 0x59, 0x41, 0xca, 0xbc, 0xbe, 0xd6, 0xf8, 0x0c, 0xfb, 0x97, 0xff, 0xf6, 0x69, 0x29, 0x1f,
-	0x36, 0x5f, 0x93, 0x20, 0x87, 0x49, 0x90, 0x9f, 0x49, 0x90, 0x8f, 0x59, 0x24, 0x87, 0x59, 0x24,
-	0xdf, 0xb3, 0x48, 0xde, 0x6e, 0x7b, 0x1d, 0x86, 0x77, 0xb9, 0x6e, 0xed, 0x58, 0xb5, 0x6a, 0xa7,
-	0x30, 0x68, 0xb0, 0xbe, 0xaf, 0xcc, 0x18, 0x2a, 0x27, 0xe5, 0xd9, 0x71, 0xe7, 0xfd, 0x6f, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x9a, 0x8c, 0xe3, 0x46, 0xf9, 0x00, 0x00, 0x00,
+	// 387 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xcd, 0xea, 0xd3, 0x40,
+	0x14, 0xc5, 0x3b, 0x9d, 0xf4, 0xdf, 0xf4, 0xf6, 0x03, 0x09, 0x0a, 0x03, 0x62, 0x0c, 0x01, 0x21,
+	0xab, 0x66, 0xe1, 0xa2, 0x7b, 0xdd, 0x28, 0x58, 0x91, 0xb8, 0x73, 0x13, 0x26, 0xc9, 0x34, 0x19,
+	0x68, 0x32, 0x21, 0x33, 0x85, 0xd2, 0xa7, 0xf0, 0x59, 0x5c, 0xf9, 0x08, 0x2e, 0xbb, 0x74, 0x29,
+	0xed, 0x8b, 0x48, 0x66, 0x4c, 0x5a, 0xa1, 0x64, 0x97, 0x73, 0xee, 0xc9, 0x70, 0xe6, 0x77, 0x07,
+	0x56, 0x75, 0x12, 0xd6, 0x8d, 0x10, 0xbb, 0x75, 0xdd, 0x08, 0x25, 0x1c, 0xfb, 0x9f, 0x48, 0xfc,
+	0x9f, 0x08, 0x26, 0x5f, 0x5a, 0xe1, 0x3c, 0x87, 0x89, 0x54, 0xb4, 0x51, 0x04, 0x79, 0x28, 0xc0,
+	0x91, 0x11, 0xce, 0x33, 0xc0, 0xac, 0xca, 0xc8, 0x58, 0x7b, 0xed, 0x67, 0x9b, 0xab, 0x44, 0xc6,
+	0x24, 0xc1, 0x1e, 0x0e, 0x16, 0x91, 0x11, 0xce, 0x4b, 0x98, 0xed, 0x19, 0xdd, 0xc5, 0x05, 0x95,
+	0x05, 0xb1, 0x3c, 0x14, 0x2c, 0x22, 0xbb, 0x35, 0x3e, 0x50, 0x59, 0x38, 0x1b, 0x20, 0x5c, 0xc6,
+	0x25, 0x3d, 0xc6, 0x15, 0x2d, 0x99, 0xac, 0x69, 0xca, 0x62, 0x9e, 0x57, 0xa2, 0x61, 0x19, 0x99,
+	0x78, 0x28, 0xb0, 0xa3, 0x17, 0x5c, 0x6e, 0xe9, 0xf1, 0x73, 0x37, 0xfd, 0x68, 0x86, 0xed, 0xa9,
+	0xaa, 0x61, 0x2c, 0x96, 0xfc, 0xc4, 0xc8, 0x93, 0xee, 0x60, 0xb7, 0xc6, 0x57, 0x7e, 0x62, 0xfe,
+	0x01, 0x60, 0x7b, 0xd8, 0x2b, 0x6e, 0xea, 0x13, 0x98, 0xf2, 0x2a, 0xe3, 0x29, 0x93, 0x04, 0x79,
+	0x38, 0xc0, 0x51, 0x27, 0x6f, 0x85, 0xc7, 0xf7, 0x85, 0x87, 0x3a, 0xe1, 0x81, 0x4e, 0xfe, 0x8f,
+	0x31, 0x2c, 0xde, 0x8b, 0xb2, 0xa6, 0xa9, 0x7a, 0x00, 0xce, 0x7a, 0x00, 0xce, 0x32, 0xe0, 0xfe,
+	0xbb, 0x0c, 0xd6, 0x7e, 0x7f, 0x99, 0xc1, 0x3a, 0xd6, 0x10, 0x22, 0x1f, 0x96, 0x05, 0x95, 0xf1,
+	0x0d, 0xbe, 0x01, 0x3a, 0x2f, 0xa8, 0xfc, 0xd4, 0xf1, 0x7f, 0x03, 0xab, 0xdb, 0xa9, 0x3d, 0xcb,
+	0x65, 0xb4, 0xec, 0x5d, 0xdd, 0xe1, 0x35, 0xcc, 0x33, 0x9e, 0x33, 0xa9, 0x4c, 0x66, 0xaa, 0x33,
+	0x60, 0x2c, 0x1d, 0x70, 0x01, 0xfa, 0x3f, 0x24, 0xb1, 0xf5, 0x96, 0xef, 0x9c, 0x76, 0x07, 0x26,
+	0x2d, 0xc9, 0x4c, 0x0f, 0x3b, 0xf9, 0x6e, 0xf3, 0xeb, 0xe2, 0xa2, 0xf3, 0xc5, 0x45, 0x7f, 0x2e,
+	0x2e, 0xfa, 0x7e, 0x75, 0x47, 0xe7, 0xab, 0x3b, 0xfa, 0x7d, 0x75, 0x47, 0xdf, 0x5e, 0xe5, 0x5c,
+	0x15, 0x87, 0x64, 0x9d, 0x8a, 0x32, 0x4c, 0xd9, 0x9e, 0x49, 0xc5, 0xa9, 0x68, 0xf2, 0xb0, 0x2a,
+	0x55, 0x58, 0x27, 0xc9, 0x93, 0x7e, 0xb0, 0x6f, 0xff, 0x0e, 0x00, 0x7d, 0x2a, 0xee, 0xae, 0xc2,
+	0x02, 0x00, 0x00,
 }
 
 func (m *Proof) Marshal() (dAtA []byte, err error) {
@@ -152,6 +366,11 @@ func (m *Proof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.TreeSize != 0 {
+		i = encodeVarintProof(dAtA, i, uint64(m.TreeSize))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.IsMaxNamespaceIgnored {
 		i--
 		if m.IsMaxNamespaceIgnored {
@@ -191,6 +410,149 @@ func (m *Proof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *MultiProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MultiProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.IsMaxNamespaceIgnored {
+		i--
+		if m.IsMaxNamespaceIgnored {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Nodes) > 0 {
+		for iNdEx := len(m.Nodes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Nodes[iNdEx])
+			copy(dAtA[i:], m.Nodes[iNdEx])
+			i = encodeVarintProof(dAtA, i, uint64(len(m.Nodes[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Indices) > 0 {
+		dAtA2 := make([]byte, len(m.Indices)*10)
+		var j1 int
+		for _, num1 := range m.Indices {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j1++
+			}
+			dAtA2[j1] = uint8(num)
+			j1++
+		}
+		i -= j1
+		copy(dAtA[i:], dAtA2[:j1])
+		i = encodeVarintProof(dAtA, i, uint64(j1))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CompactProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CompactProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CompactProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Digests) > 0 {
+		i -= len(m.Digests)
+		copy(dAtA[i:], m.Digests)
+		i = encodeVarintProof(dAtA, i, uint64(len(m.Digests)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.Namespaces) > 0 {
+		i -= len(m.Namespaces)
+		copy(dAtA[i:], m.Namespaces)
+		i = encodeVarintProof(dAtA, i, uint64(len(m.Namespaces)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.DigestSize != 0 {
+		i = encodeVarintProof(dAtA, i, uint64(m.DigestSize))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.NamespaceSize != 0 {
+		i = encodeVarintProof(dAtA, i, uint64(m.NamespaceSize))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.HasLeafHash {
+		i--
+		if m.HasLeafHash {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.IsMaxNamespaceIgnored {
+		i--
+		if m.IsMaxNamespaceIgnored {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.TreeSize != 0 {
+		i = encodeVarintProof(dAtA, i, uint64(m.TreeSize))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.End != 0 {
+		i = encodeVarintProof(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Start != 0 {
+		i = encodeVarintProof(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintProof(dAtA []byte, offset int, v uint64) int {
 	offset -= sovProof(v)
 	base := offset
@@ -220,23 +582,460 @@ func (m *Proof) Size() (n int) {
 			n += 1 + l + sovProof(uint64(l))
 		}
 	}
-	l = len(m.LeafHash)
-	if l > 0 {
-		n += 1 + l + sovProof(uint64(l))
-	}
-	if m.IsMaxNamespaceIgnored {
-		n += 2
+	l = len(m.LeafHash)
+	if l > 0 {
+		n += 1 + l + sovProof(uint64(l))
+	}
+	if m.IsMaxNamespaceIgnored {
+		n += 2
+	}
+	if m.TreeSize != 0 {
+		n += 1 + sovProof(uint64(m.TreeSize))
+	}
+	return n
+}
+
+func (m *MultiProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Indices) > 0 {
+		l = 0
+		for _, e := range m.Indices {
+			l += sovProof(uint64(e))
+		}
+		n += 1 + sovProof(uint64(l)) + l
+	}
+	if len(m.Nodes) > 0 {
+		for _, b := range m.Nodes {
+			l = len(b)
+			n += 1 + l + sovProof(uint64(l))
+		}
+	}
+	if m.IsMaxNamespaceIgnored {
+		n += 2
+	}
+	return n
+}
+
+func (m *CompactProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovProof(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovProof(uint64(m.End))
+	}
+	if m.TreeSize != 0 {
+		n += 1 + sovProof(uint64(m.TreeSize))
+	}
+	if m.IsMaxNamespaceIgnored {
+		n += 2
+	}
+	if m.HasLeafHash {
+		n += 2
+	}
+	if m.NamespaceSize != 0 {
+		n += 1 + sovProof(uint64(m.NamespaceSize))
+	}
+	if m.DigestSize != 0 {
+		n += 1 + sovProof(uint64(m.DigestSize))
+	}
+	l = len(m.Namespaces)
+	if l > 0 {
+		n += 1 + l + sovProof(uint64(l))
+	}
+	l = len(m.Digests)
+	if l > 0 {
+		n += 1 + l + sovProof(uint64(l))
+	}
+	return n
+}
+
+func sovProof(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozProof(x uint64) (n int) {
+	return sovProof(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Proof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowProof
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Proof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Proof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nodes", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthProof
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthProof
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Nodes = append(m.Nodes, make([]byte, postIndex-iNdEx))
+			copy(m.Nodes[len(m.Nodes)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LeafHash", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthProof
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthProof
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LeafHash = append(m.LeafHash[:0], dAtA[iNdEx:postIndex]...)
+			if m.LeafHash == nil {
+				m.LeafHash = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsMaxNamespaceIgnored", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsMaxNamespaceIgnored = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TreeSize", wireType)
+			}
+			m.TreeSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TreeSize |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipProof(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthProof
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
+	return nil
 }
+func (m *MultiProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowProof
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MultiProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MultiProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v int64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowProof
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Indices = append(m.Indices, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowProof
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthProof
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthProof
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.Indices) == 0 {
+					m.Indices = make([]int64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowProof
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Indices = append(m.Indices, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Indices", wireType)
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nodes", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthProof
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthProof
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Nodes = append(m.Nodes, make([]byte, postIndex-iNdEx))
+			copy(m.Nodes[len(m.Nodes)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsMaxNamespaceIgnored", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsMaxNamespaceIgnored = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipProof(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthProof
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
 
-func sovProof(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozProof(x uint64) (n int) {
-	return sovProof(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *Proof) Unmarshal(dAtA []byte) error {
+func (m *CompactProof) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -259,10 +1058,10 @@ func (m *Proof) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Proof: wiretype end group for non-group")
+			return fmt.Errorf("proto: CompactProof: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Proof: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CompactProof: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -279,7 +1078,7 @@ func (m *Proof) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Start |= int64(b&0x7F) << shift
+				m.Start |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -298,16 +1097,16 @@ func (m *Proof) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.End |= int64(b&0x7F) << shift
+				m.End |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Nodes", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TreeSize", wireType)
 			}
-			var byteLen int
+			m.TreeSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowProof
@@ -317,27 +1116,92 @@ func (m *Proof) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.TreeSize |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthProof
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsMaxNamespaceIgnored", wireType)
 			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthProof
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.IsMaxNamespaceIgnored = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HasLeafHash", wireType)
 			}
-			m.Nodes = append(m.Nodes, make([]byte, postIndex-iNdEx))
-			copy(m.Nodes[len(m.Nodes)-1], dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 4:
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.HasLeafHash = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NamespaceSize", wireType)
+			}
+			m.NamespaceSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NamespaceSize |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DigestSize", wireType)
+			}
+			m.DigestSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProof
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DigestSize |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LeafHash", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Namespaces", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -364,16 +1228,16 @@ func (m *Proof) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.LeafHash = append(m.LeafHash[:0], dAtA[iNdEx:postIndex]...)
-			if m.LeafHash == nil {
-				m.LeafHash = []byte{}
+			m.Namespaces = append(m.Namespaces[:0], dAtA[iNdEx:postIndex]...)
+			if m.Namespaces == nil {
+				m.Namespaces = []byte{}
 			}
 			iNdEx = postIndex
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsMaxNamespaceIgnored", wireType)
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Digests", wireType)
 			}
-			var v int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowProof
@@ -383,12 +1247,26 @@ func (m *Proof) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.IsMaxNamespaceIgnored = bool(v != 0)
+			if byteLen < 0 {
+				return ErrInvalidLengthProof
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthProof
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Digests = append(m.Digests[:0], dAtA[iNdEx:postIndex]...)
+			if m.Digests == nil {
+				m.Digests = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipProof(dAtA[iNdEx:])
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"math"
 	"math/bits"
 	"slices"
 
@@ -17,6 +18,14 @@ var (
 	// ErrFailedCompletenessCheck indicates that the verification of a namespace proof failed due to the lack of completeness property.
 	ErrFailedCompletenessCheck = errors.New("failed completeness check")
 	ErrWrongLeafHashesSize     = errors.New("wrong leafHashes size")
+	// ErrRootMismatch indicates that the root computed from a proof does not match the expected root.
+	ErrRootMismatch = errors.New("root mismatch")
+	// ErrInvalidNodeFormat indicates that a proof node, a leaf hash or a root does not conform to the namespaced hash format.
+	ErrInvalidNodeFormat = errors.New("invalid node format")
+	// ErrNamespaceMismatch indicates that a leaf does not belong to the namespace being verified.
+	ErrNamespaceMismatch = errors.New("namespace mismatch")
+	// ErrInvalidNodeCount indicates that a proof does not contain the nodes required by its range.
+	ErrInvalidNodeCount = errors.New("invalid number of proof nodes")
 )
 
 // Proof represents a namespace proof of a namespace.ID in an NMT. In case this
@@ -51,17 +60,18 @@ type Proof struct {
 	// omitted if feasible. For a more in-depth understanding of this field,
 	// refer to the "HashNode" method in the "Hasher.
 	isMaxNamespaceIDIgnored bool
+	// treeSize is the number of leaves of the tree from which this Proof was
+	// generated, or 0 if it is unknown, e.g., for proofs serialized before
+	// this field was introduced. If set, the verification checks that the
+	// proof holds exactly the nodes required by the shape of a tree of that
+	// size, instead of estimating the shape of the tree from the proof range.
+	// Note that the root does not commit to the size of the tree, so the tree
+	// size is only checked to be consistent with the proof.
+	treeSize int
 }
 
 func (proof Proof) MarshalJSON() ([]byte, error) {
-	pbProofObj := pb.Proof{
-		Start:                 int64(proof.start),
-		End:                   int64(proof.end),
-		Nodes:                 proof.nodes,
-		LeafHash:              proof.leafHash,
-		IsMaxNamespaceIgnored: proof.isMaxNamespaceIDIgnored,
-	}
-	return json.Marshal(pbProofObj)
+	return json.Marshal(ProofToProto(proof))
 }
 
 func (proof *Proof) UnmarshalJSON(data []byte) error {
@@ -75,6 +85,28 @@ func (proof *Proof) UnmarshalJSON(data []byte) error {
 	proof.nodes = pbProof.Nodes
 	proof.leafHash = pbProof.LeafHash
 	proof.isMaxNamespaceIDIgnored = pbProof.IsMaxNamespaceIgnored
+	proof.treeSize = int(pbProof.TreeSize)
+	return nil
+}
+
+// Marshal encodes the proof in the binary protobuf format of pb.Proof.
+func (proof Proof) Marshal() ([]byte, error) {
+	pbProof := ProofToProto(proof)
+	return pbProof.Marshal()
+}
+
+// Unmarshal decodes a proof encoded by Marshal. Unlike ProtoToProof, it
+// rejects malformed proofs, see ProtoToProofStrict.
+func (proof *Proof) Unmarshal(data []byte) error {
+	var pbProof pb.Proof
+	if err := pbProof.Unmarshal(data); err != nil {
+		return err
+	}
+	decoded, err := ProtoToProofStrict(pbProof)
+	if err != nil {
+		return err
+	}
+	*proof = decoded
 	return nil
 }
 
@@ -119,22 +151,36 @@ func (proof Proof) IsMaxNamespaceIDIgnored() bool {
 	return proof.isMaxNamespaceIDIgnored
 }
 
+// TreeSize returns the number of leaves of the tree from which the proof was
+// generated, or 0 if it is unknown.
+func (proof Proof) TreeSize() int {
+	return proof.treeSize
+}
+
+// WithTreeSize returns a copy of the proof bound to a tree of treeSize leaves,
+// see TreeSize. The verification of the returned proof fails if it does not
+// hold exactly the nodes required by the shape of such a tree.
+func (proof Proof) WithTreeSize(treeSize int) Proof {
+	proof.treeSize = treeSize
+	return proof
+}
+
 // NewEmptyRangeProof constructs a proof that proves that a namespace.ID does
 // not fall within the range of an NMT.
 func NewEmptyRangeProof(ignoreMaxNamespace bool) Proof {
-	return Proof{0, 0, nil, nil, ignoreMaxNamespace}
+	return Proof{isMaxNamespaceIDIgnored: ignoreMaxNamespace}
 }
 
 // NewInclusionProof constructs a proof that proves that a namespace.ID is
 // included in an NMT.
 func NewInclusionProof(proofStart, proofEnd int, proofNodes [][]byte, ignoreMaxNamespace bool) Proof {
-	return Proof{proofStart, proofEnd, proofNodes, nil, ignoreMaxNamespace}
+	return Proof{start: proofStart, end: proofEnd, nodes: proofNodes, isMaxNamespaceIDIgnored: ignoreMaxNamespace}
 }
 
 // NewAbsenceProof constructs a proof that proves that a namespace.ID falls
 // within the range of an NMT but no leaf with that namespace.ID is included.
 func NewAbsenceProof(proofStart, proofEnd int, proofNodes [][]byte, leafHash []byte, ignoreMaxNamespace bool) Proof {
-	return Proof{proofStart, proofEnd, proofNodes, leafHash, ignoreMaxNamespace}
+	return Proof{start: proofStart, end: proofEnd, nodes: proofNodes, leafHash: leafHash, isMaxNamespaceIDIgnored: ignoreMaxNamespace}
 }
 
 // IsEmptyProof checks whether the proof corresponds to an empty proof as defined in NMT specifications https://github.com/celestiaorg/nmt/blob/main/docs/spec/nmt.md.
@@ -142,13 +188,19 @@ func (proof Proof) IsEmptyProof() bool {
 	return proof.start == proof.end && len(proof.nodes) == 0 && len(proof.leafHash) == 0
 }
 
-func (proof Proof) isValidEmptyRangeProof(nth *NmtHasher, nID namespace.ID, root []byte, leaves [][]byte, checkNS bool) bool {
-	if !proof.IsEmptyProof() || len(leaves) != 0 {
-		return false
+// validateEmptyRangeProof checks that the proof is a valid empty proof for
+// the namespaces in the interval [lo, hi], i.e., a single namespace if lo and
+// hi are equal.
+func (proof Proof) validateEmptyRangeProof(nth ValidatingHasher, lo, hi namespace.ID, root []byte, leaves [][]byte, checkNS bool) error {
+	if !proof.IsEmptyProof() {
+		return fmt.Errorf("%w: empty proof range with nodes or leaf hash", ErrInvalidRange)
+	}
+	if len(leaves) != 0 {
+		return fmt.Errorf("supplied %d leaves for an empty proof range: %w", len(leaves), ErrWrongLeafHashesSize)
 	}
 
 	if !checkNS {
-		return true
+		return nil
 	}
 
 	// validate the root format before slicing its namespace bounds below.
@@ -157,31 +209,34 @@ func (proof Proof) isValidEmptyRangeProof(nth *NmtHasher, nID namespace.ID, root
 	// returning a verification failure. The non-empty path performs the
 	// equivalent validation via nth.ValidateNodeFormat(root) in VerifyLeafHashes.
 	if err := nth.ValidateNodeFormat(root); err != nil {
-		return false
+		return fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 	}
 
-	nIDLen := nID.Size()
+	nIDLen := lo.Size()
 	rootMin := namespace.ID(MinNamespace(root, nIDLen))
 	rootMax := namespace.ID(MaxNamespace(root, nIDLen))
 
-	// empty proofs are always rejected unless 1) nID is outside the range of
-	// namespaces covered by the root 2) the root represents an empty tree, since
-	// it purports to cover the zero namespace but does not actually include
-	// any such nodes
-	return nID.Less(rootMin) || rootMax.Less(nID) || bytes.Equal(root, nth.EmptyRoot())
+	// empty proofs are always rejected unless 1) [lo, hi] is outside the range
+	// of namespaces covered by the root 2) the root represents an empty tree,
+	// since it purports to cover the zero namespace but does not actually
+	// include any such nodes
+	if hi.Less(rootMin) || rootMax.Less(lo) || bytes.Equal(root, nth.EmptyRoot()) {
+		return nil
+	}
+	return fmt.Errorf("%w: empty proof for namespaces within the range of the root", ErrFailedCompletenessCheck)
 }
 
 // ComputeAndValidateLeafHashes validates and hashes a list of leaves using the provided NMT hasher.
-func ComputeAndValidateLeafHashes(nth *NmtHasher, nid namespace.ID, leaves [][]byte) ([][]byte, error) {
+func ComputeAndValidateLeafHashes(nth ValidatingHasher, nid namespace.ID, leaves [][]byte) ([][]byte, error) {
 	hashes := make([][]byte, len(leaves))
 	for i, leaf := range leaves {
-		if nth.ValidateLeaf(leaf) != nil {
-			return nil, fmt.Errorf("invalid leaf data: does not contain the expected namespace prefix")
+		if err := nth.ValidateLeaf(leaf); err != nil {
+			return nil, fmt.Errorf("invalid leaf data: does not contain the expected namespace prefix: %w", err)
 		}
 		// check whether the namespace ID of the data matches the queried nID
 		if leafNid := namespace.ID(leaf[:nid.Size()]); !leafNid.Equal(nid) {
 			// conflicting namespace IDs in data
-			return nil, fmt.Errorf("leaf with namespace ID %x does not belong to expected namespace %x", leafNid, nid)
+			return nil, fmt.Errorf("%w: leaf with namespace ID %x does not belong to expected namespace %x", ErrNamespaceMismatch, leafNid, nid)
 		}
 		hash, err := nth.HashLeaf(leaf)
 		if err != nil {
@@ -193,7 +248,7 @@ func ComputeAndValidateLeafHashes(nth *NmtHasher, nid namespace.ID, leaves [][]b
 }
 
 // ComputePrefixedLeafHashes computes NMT leaf hashes for raw leaf data by prepending the given namespace ID.
-func ComputePrefixedLeafHashes(nth *NmtHasher, nid namespace.ID, leaves [][]byte) ([][]byte, error) {
+func ComputePrefixedLeafHashes(nth ValidatingHasher, nid namespace.ID, leaves [][]byte) ([][]byte, error) {
 	hashes := make([][]byte, len(leaves))
 	for i, leaf := range leaves {
 		// prepend the namespace to the leaf data and hash it
@@ -226,12 +281,35 @@ func ComputePrefixedLeafHashes(nth *NmtHasher, nid namespace.ID, leaves [][]byte
 //
 // `root` is the root of the NMT against which the `proof` is verified.
 func (proof Proof) VerifyNamespace(h hash.Hash, nID namespace.ID, leaves [][]byte, root []byte) bool {
-	nIDLen := nID.Size()
-	nth := NewNmtHasher(h, nIDLen, proof.isMaxNamespaceIDIgnored)
+	return proof.VerifyNamespaceErr(h, nID, leaves, root) == nil
+}
 
+// VerifyNamespaceErr is like VerifyNamespace, but returns an error describing
+// why the verification failed instead of false, and nil if the proof is valid.
+// The error wraps one of the following sentinel errors, which can be checked
+// with errors.Is:
+//   - ErrRootMismatch: the proof is well-formed but does not lead to root.
+//   - ErrInvalidNodeFormat: a proof node, a leaf hash or root does not
+//     conform to the namespaced hash format.
+//   - ErrNamespaceMismatch: a leaf does not belong to the namespace nID.
+//   - ErrInvalidLeafLen: a leaf is too short to be prefixed with nID.
+//   - ErrFailedCompletenessCheck: leaves of the namespace nID may have been
+//     left out of the proof.
+//   - ErrInvalidRange, ErrWrongLeafHashesSize, ErrInvalidNodeCount: the range
+//     of the proof, the number of leaves or the number of proof nodes is
+//     invalid.
+func (proof Proof) VerifyNamespaceErr(h hash.Hash, nID namespace.ID, leaves [][]byte, root []byte) error {
+	return proof.VerifyNamespaceWithHasher(NewNmtHasher(h, nID.Size(), proof.isMaxNamespaceIDIgnored), nID, leaves, root)
+}
+
+// VerifyNamespaceWithHasher is like VerifyNamespaceErr, but hashes the leaves
+// and nodes with nth instead of an NmtHasher of the base hash function h. It
+// verifies the proofs of a tree with a custom hasher, see CustomHasher; nth
+// must then be configured like the hasher of the tree.
+func (proof Proof) VerifyNamespaceWithHasher(nth ValidatingHasher, nID namespace.ID, leaves [][]byte, root []byte) error {
 	// if empty range proof, check that the proof is valid
 	if proof.start == proof.end {
-		return proof.isValidEmptyRangeProof(nth, nID, root, leaves, true)
+		return proof.validateEmptyRangeProof(nth, nID, nID, root, leaves, true)
 	}
 
 	gotLeafHashes := make([][]byte, 0, len(leaves))
@@ -241,19 +319,100 @@ func (proof Proof) VerifyNamespace(h hash.Hash, nID namespace.ID, leaves [][]byt
 		var err error
 		gotLeafHashes, err = ComputeAndValidateLeafHashes(nth, nID, leaves)
 		if err != nil {
-			return false
+			return err
 		}
 	}
 
 	// with verifyCompleteness set to true:
 	res, err := proof.VerifyLeafHashes(nth, true, nID, gotLeafHashes, root)
 	if err != nil {
-		return false
+		return err
+	}
+	if !res {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// VerifyNamespaceRange verifies all the namespaces in the interval [lo, hi],
+// i.e., it verifies the inclusion of the provided `leaves` in the tree, and
+// that no leaf with a namespace ID in [lo, hi] was left out, see
+// NamespacedMerkleTree.ProveNamespaceRange. `leaves` MUST be ordered according
+// to their index in the tree, and hence by namespace ID, and may belong to
+// several namespaces of the interval. For an absence proof, i.e., if the tree
+// has no leaves in the interval, `leaves` is empty.
+//
+// `h` MUST be the same as the underlying hash function used to generate the
+// proof. `root` is the root of the NMT against which the `proof` is verified.
+func (proof Proof) VerifyNamespaceRange(h hash.Hash, lo, hi namespace.ID, leaves [][]byte, root []byte) bool {
+	return proof.VerifyNamespaceRangeErr(h, lo, hi, leaves, root) == nil
+}
+
+// VerifyNamespaceRangeErr is like VerifyNamespaceRange, but returns an error
+// describing why the verification failed instead of false, and nil if the
+// proof is valid. The error wraps the same sentinel errors as
+// VerifyNamespaceErr, where ErrNamespaceMismatch reports a leaf that is out of
+// order or outside the interval [lo, hi], and ErrInvalidRange an invalid
+// interval as well.
+func (proof Proof) VerifyNamespaceRangeErr(h hash.Hash, lo, hi namespace.ID, leaves [][]byte, root []byte) error {
+	if lo.Size() != hi.Size() || hi.Less(lo) {
+		return fmt.Errorf("%w: namespace interval [%x, %x]", ErrInvalidRange, lo, hi)
+	}
+	nth := NewNmtHasher(h, lo.Size(), proof.isMaxNamespaceIDIgnored)
+
+	// if empty range proof, check that the proof is valid
+	if proof.start == proof.end {
+		return proof.validateEmptyRangeProof(nth, lo, hi, root, leaves, true)
+	}
+
+	gotLeafHashes := make([][]byte, 0, len(leaves))
+	if proof.IsOfAbsence() {
+		if len(leaves) != 0 {
+			return fmt.Errorf("supplied %d leaves for an absence proof: %w", len(leaves), ErrWrongLeafHashesSize)
+		}
+		gotLeafHashes = append(gotLeafHashes, proof.leafHash)
+	} else {
+		prevNID := lo
+		for i, leaf := range leaves {
+			if err := nth.ValidateLeaf(leaf); err != nil {
+				return fmt.Errorf("invalid leaf data: does not contain the expected namespace prefix: %w", err)
+			}
+			// the leaves must be sorted and within the interval
+			leafNID := namespace.ID(leaf[:lo.Size()])
+			if leafNID.Less(prevNID) || hi.Less(leafNID) {
+				return fmt.Errorf("%w: leaf %d of namespace %x is out of order or outside [%x, %x]", ErrNamespaceMismatch, i, leafNID, lo, hi)
+			}
+			prevNID = leafNID
+			hash, err := nth.HashLeaf(leaf)
+			if err != nil {
+				return fmt.Errorf("failed to hash leaf %d: %w", i, err)
+			}
+			gotLeafHashes = append(gotLeafHashes, hash)
+		}
 	}
-	return res
+
+	// an absence proof must prove that the leaf hash is larger than the
+	// whole interval
+	if err := proof.validateProofStructure(nth, hi, gotLeafHashes); err != nil {
+		return err
+	}
+	if err := nth.ValidateNodeFormat(root); err != nil {
+		return fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+	}
+	if err := proof.validateIntervalCompleteness(nth, lo, hi); err != nil {
+		return err
+	}
+	rootHash, err := proof.computeRoot(nth, gotLeafHashes)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(rootHash, root) {
+		return ErrRootMismatch
+	}
+	return nil
 }
 
-func (proof Proof) validateProofStructure(nth *NmtHasher, nID namespace.ID, leafHashes [][]byte) error {
+func (proof Proof) validateProofStructure(nth ValidatingHasher, nID namespace.ID, leafHashes [][]byte) error {
 	// check that the proof range is valid
 	if proof.Start() < 0 || proof.Start() >= proof.End() {
 		return fmt.Errorf("proof range [proof.start=%d, proof.end=%d) is not valid: %w", proof.Start(), proof.End(), ErrInvalidRange)
@@ -266,54 +425,96 @@ func (proof Proof) validateProofStructure(nth *NmtHasher, nID namespace.ID, leaf
 		return fmt.Errorf("supplied leafHashes size %d, expected size %d: %w", len(leafHashes), expectedLeafHashesCount, ErrWrongLeafHashesSize)
 	}
 
+	if err := proof.validateTreeSize(); err != nil {
+		return err
+	}
+
 	// if the proof is an absence proof,
 	// the leafHash must be valid w.r.t the NMT hasher and queried namespace ID
 	if proof.IsOfAbsence() {
 		if err := nth.ValidateNodeFormat(proof.leafHash); err != nil {
-			return fmt.Errorf("leaf hash does not match the NMT hasher's hash format: %w", err)
+			return fmt.Errorf("%w: leaf hash does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 		}
 		// conduct some sanity checks:
 		leafMinNID := namespace.ID(proof.leafHash[:nID.Size()])
 		if !nID.Less(leafMinNID) {
 			// leafHash.minNID  must be greater than nID
-			return fmt.Errorf("leaf hash %x does not belong to namespace %x", proof.leafHash, nID)
+			return fmt.Errorf("%w: leaf hash %x does not belong to namespace %x", ErrNamespaceMismatch, proof.leafHash, nID)
 		}
 	}
 
 	// check that namespace ID size matches the NMT hasher's namespace size
 	if nID.Size() != nth.NamespaceSize() {
-		return fmt.Errorf("namespace ID size (%d) does not match the namespace size of the NMT hasher (%d)", nID.Size(), nth.NamespaceSize())
+		return fmt.Errorf("%w: namespace ID size (%d) does not match the namespace size of the NMT hasher (%d)", ErrNamespaceMismatch, nID.Size(), nth.NamespaceSize())
 	}
 
 	// check that all the proof.nodes are valid w.r.t the NMT hasher
 	for _, node := range proof.nodes {
 		if err := nth.ValidateNodeFormat(node); err != nil {
-			return fmt.Errorf("proof nodes do not match the NMT hasher's hash format: %w", err)
+			return fmt.Errorf("%w: proof nodes do not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 		}
 	}
 
 	// check that all the leafHashes are valid w.r.t the NMT hasher
 	for _, leafHash := range leafHashes {
 		if err := nth.ValidateNodeFormat(leafHash); err != nil {
-			return fmt.Errorf("leaf hash does not match the NMT hasher's hash format: %w", err)
+			return fmt.Errorf("%w: leaf hash does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 		}
 	}
 
 	return nil
 }
 
-func (proof Proof) validateNamespace(nth *NmtHasher, nID namespace.ID, leafHashes [][]byte) error {
+// validateTreeSize checks that the proof range fits in the tree and that the
+// proof holds exactly the nodes required by the shape of the tree, if the size
+// of the tree is known.
+func (proof Proof) validateTreeSize() error {
+	if proof.treeSize == 0 {
+		return nil
+	}
+	if proof.treeSize < 0 || proof.End() > proof.treeSize {
+		return fmt.Errorf("proof range [proof.start=%d, proof.end=%d) is not valid for a tree of size %d: %w", proof.Start(), proof.End(), proof.treeSize, ErrInvalidRange)
+	}
+	if want := proofNodeCount(0, proof.treeSize, proof.Start(), proof.End()); len(proof.nodes) != want {
+		return fmt.Errorf("%w: got %d proof nodes, expected %d for a tree of size %d", ErrInvalidNodeCount, len(proof.nodes), want, proof.treeSize)
+	}
+	return nil
+}
+
+// proofNodeCount returns the number of nodes of the proof of the leaves in
+// [start, end) in the subtree of the leaves in [treeStart, treeEnd), i.e., the
+// number of its maximal subtrees that do not overlap [start, end).
+func proofNodeCount(treeStart, treeEnd, start, end int) int {
+	if treeEnd <= start || end <= treeStart {
+		return 1
+	}
+	if start <= treeStart && treeEnd <= end {
+		return 0
+	}
+	k := getSplitPoint(treeEnd - treeStart)
+	return proofNodeCount(treeStart, treeStart+k, start, end) + proofNodeCount(treeStart+k, treeEnd, start, end)
+}
+
+func (proof Proof) validateNamespace(nth ValidatingHasher, nID namespace.ID, leafHashes [][]byte) error {
 	for _, leafHash := range leafHashes {
 		minNsID := MinNamespace(leafHash, nth.NamespaceSize())
 		maxNsID := MaxNamespace(leafHash, nth.NamespaceSize())
 		if !nID.Equal(minNsID) || !nID.Equal(maxNsID) {
-			return fmt.Errorf("leaf hash %x does not belong to namespace %x", leafHash, nID)
+			return fmt.Errorf("%w: leaf hash %x does not belong to namespace %x", ErrNamespaceMismatch, leafHash, nID)
 		}
 	}
 	return nil
 }
 
-func (proof Proof) validateCompleteness(nth *NmtHasher, nID namespace.ID) error {
+func (proof Proof) validateCompleteness(nth ValidatingHasher, nID namespace.ID) error {
+	return proof.validateIntervalCompleteness(nth, nID, nID)
+}
+
+// validateIntervalCompleteness checks that the proof range holds all the leaves
+// of the namespaces in the interval [lo, hi], i.e., that the namespace IDs of
+// the subtrees on its left are all smaller than lo, and those of the subtrees
+// on its right all larger than hi.
+func (proof Proof) validateIntervalCompleteness(nth ValidatingHasher, lo, hi namespace.ID) error {
 	var leafIndex uint64
 	// leftSubtrees is to be populated by the subtree roots upto [0, r.Start)
 	leftSubtrees := make([][]byte, 0, len(proof.nodes))
@@ -341,13 +542,13 @@ func (proof Proof) validateCompleteness(nth *NmtHasher, nID namespace.ID) error
 	// leftSubtrees contains the subtree roots upto [0, r.Start)
 	for _, subtree := range leftSubtrees {
 		leftSubTreeMax := MaxNamespace(subtree, nth.NamespaceSize())
-		if nID.LessOrEqual(namespace.ID(leftSubTreeMax)) {
+		if lo.LessOrEqual(namespace.ID(leftSubTreeMax)) {
 			return ErrFailedCompletenessCheck
 		}
 	}
 	for _, subtree := range rightSubtrees {
 		rightSubTreeMin := MinNamespace(subtree, nth.NamespaceSize())
-		if namespace.ID(rightSubTreeMin).LessOrEqual(nID) {
+		if namespace.ID(rightSubTreeMin).LessOrEqual(hi) {
 			return ErrFailedCompletenessCheck
 		}
 	}
@@ -370,7 +571,7 @@ func (proof Proof) validateCompleteness(nth *NmtHasher, nID namespace.ID) error
 // Returns:
 //   - The computed root hash if all checks pass.
 //   - An error if any validation fails or root computation fails.
-func (proof Proof) ComputeRootWithBasicValidation(nth *NmtHasher, nID namespace.ID, leafHashes [][]byte, isNamespace bool) ([]byte, error) {
+func (proof Proof) ComputeRootWithBasicValidation(nth ValidatingHasher, nID namespace.ID, leafHashes [][]byte, isNamespace bool) ([]byte, error) {
 	if err := proof.validateProofStructure(nth, nID, leafHashes); err != nil {
 		return nil, err
 	}
@@ -390,73 +591,15 @@ func (proof Proof) ComputeRootWithBasicValidation(nth *NmtHasher, nID namespace.
 	}
 
 	if err := nth.ValidateNodeFormat(rootHash); err != nil {
-		return nil, fmt.Errorf("root does not match the NMT hasher's hash format: %w", err)
+		return nil, fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 	}
 
 	return rootHash, nil
 }
 
-func (proof Proof) computeRoot(nth *NmtHasher, leafHashes [][]byte) ([]byte, error) {
-	var computeRoot func(start, end int) ([]byte, error)
-	// computeRoot can return error iff the HashNode function fails while calculating the root
-	computeRoot = func(start, end int) ([]byte, error) {
-		// reached a leaf
-		if end-start == 1 {
-			// if the leaf index falls within the proof range, pop and return a
-			// leaf
-			if start >= proof.Start() && start < proof.End() {
-				// advance leafHashes
-				return popIfNonEmpty(&leafHashes), nil
-			}
-
-			// if the leaf index  is outside the proof range, pop and return a
-			// proof node (which in this case is a leaf) if present, else return
-			// nil because leaf doesn't exist
-			return popIfNonEmpty(&proof.nodes), nil
-		}
-
-		// if current range does not overlap with the proof range, pop and
-		// return a proof node if present, else return nil because subtree
-		// doesn't exist
-		if end <= proof.Start() || start >= proof.End() {
-			return popIfNonEmpty(&proof.nodes), nil
-		}
-
-		// Recursively get left and right subtree
-		k := getSplitPoint(end - start)
-		left, err := computeRoot(start, start+k)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start, start+k, err)
-		}
-		right, err := computeRoot(start+k, end)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compute subtree root [%d, %d): %w", start+k, end, err)
-		}
-
-		// only right leaf/subtree can be non-existent
-		if right == nil {
-			return left, nil
-		}
-		hash, err := nth.HashNode(left, right)
-		if err != nil {
-			return nil, fmt.Errorf("failed to hash node: %w", err)
-		}
-		return hash, nil
-	}
-
-	// estimate the leaf size of the subtree containing the proof range
-	proofRangeSubtreeEstimate := max(getSplitPoint(proof.end)*2, 1)
-	rootHash, err := computeRoot(0, proofRangeSubtreeEstimate)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute root [%d, %d): %w", 0, proofRangeSubtreeEstimate, err)
-	}
-	for _, node := range proof.nodes {
-		rootHash, err = nth.HashNode(rootHash, node)
-		if err != nil {
-			return nil, fmt.Errorf("failed to hash node: %w", err)
-		}
-	}
-	return rootHash, nil
+func (proof Proof) computeRoot(nth ValidatingHasher, leafHashes [][]byte) ([]byte, error) {
+	rootHash, _, err := computeRangesRoot(nth, []LeafRange{{Start: proof.start, End: proof.end}}, leafHashes, proof.nodes, proof.treeSize, nil)
+	return rootHash, err
 }
 
 // The VerifyLeafHashes function checks whether the given proof is a valid Merkle
@@ -469,14 +612,14 @@ func (proof Proof) computeRoot(nth *NmtHasher, leafHashes [][]byte) ([]byte, err
 // the completeness of the proof by verifying that there is no leaf in the
 // tree represented by the root parameter that matches the namespace ID nID
 // outside the leafHashes list.
-func (proof Proof) VerifyLeafHashes(nth *NmtHasher, verifyCompleteness bool, nID namespace.ID, leafHashes [][]byte, root []byte) (bool, error) {
+func (proof Proof) VerifyLeafHashes(nth ValidatingHasher, verifyCompleteness bool, nID namespace.ID, leafHashes [][]byte, root []byte) (bool, error) {
 	if err := proof.validateProofStructure(nth, nID, leafHashes); err != nil {
 		return false, err
 	}
 
 	// check that the root is valid w.r.t the NMT hasher
 	if err := nth.ValidateNodeFormat(root); err != nil {
-		return false, fmt.Errorf("root does not match the NMT hasher's hash format: %w", err)
+		return false, fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 	}
 
 	// check that the namespace of leafHashes is the same as the queried namespace, except for the case of absence proof
@@ -507,24 +650,40 @@ func (proof Proof) VerifyLeafHashes(nth *NmtHasher, verifyCompleteness bool, nID
 // The size of the leavesWithoutNamespace should be equal to the proof range i.e., end-start.
 // VerifyInclusion does not verify the completeness of the proof, so it's possible for leavesWithoutNamespace to be a subset of the leaves in the tree that have the namespace ID nid.
 func (proof Proof) VerifyInclusion(h hash.Hash, nid namespace.ID, leavesWithoutNamespace [][]byte, root []byte) bool {
-	nth := NewNmtHasher(h, nid.Size(), proof.isMaxNamespaceIDIgnored)
+	return proof.VerifyInclusionErr(h, nid, leavesWithoutNamespace, root) == nil
+}
+
+// VerifyInclusionErr is like VerifyInclusion, but returns an error describing
+// why the verification failed instead of false, and nil if the proof is valid.
+// The error wraps the same sentinel errors as VerifyNamespaceErr, except for
+// ErrFailedCompletenessCheck since completeness is not verified.
+func (proof Proof) VerifyInclusionErr(h hash.Hash, nid namespace.ID, leavesWithoutNamespace [][]byte, root []byte) error {
+	return proof.VerifyInclusionWithHasher(NewNmtHasher(h, nid.Size(), proof.isMaxNamespaceIDIgnored), nid, leavesWithoutNamespace, root)
+}
 
+// VerifyInclusionWithHasher is like VerifyInclusionErr, but hashes the leaves
+// and nodes with nth instead of an NmtHasher of the base hash function h, see
+// VerifyNamespaceWithHasher.
+func (proof Proof) VerifyInclusionWithHasher(nth ValidatingHasher, nid namespace.ID, leavesWithoutNamespace [][]byte, root []byte) error {
 	// validate empty proof range
 	if proof.start == proof.end {
-		return proof.isValidEmptyRangeProof(nth, nid, root, leavesWithoutNamespace, false)
+		return proof.validateEmptyRangeProof(nth, nid, nid, root, leavesWithoutNamespace, false)
 	}
 
 	// add namespace to all the leaves
 	hashes, err := ComputePrefixedLeafHashes(nth, nid, leavesWithoutNamespace)
 	if err != nil {
-		return false
+		return err
 	}
 
 	res, err := proof.VerifyLeafHashes(nth, false, nid, hashes, root)
 	if err != nil {
-		return false
+		return err
+	}
+	if !res {
+		return ErrRootMismatch
 	}
-	return res
+	return nil
 }
 
 // VerifySubtreeRootInclusion verifies that a set of subtree roots is included in
@@ -536,7 +695,8 @@ func (proof Proof) VerifyInclusion(h hash.Hash, nid namespace.ID, leavesWithoutN
 // - The tree's number of leaves is a power of two
 // The subtreeWidth is also defined in ADR-013.
 // More information on the algorithm used can be found in the ToLeafRanges() method docs.
-func (proof Proof) VerifySubtreeRootInclusion(nth *NmtHasher, subtreeRoots [][]byte, subtreeWidth int, root []byte) (bool, error) {
+// See VerifySubtreeRoots for trees of any size.
+func (proof Proof) VerifySubtreeRootInclusion(nth ValidatingHasher, subtreeRoots [][]byte, subtreeWidth int, root []byte) (bool, error) {
 	// check that the proof range is valid
 	if proof.Start() < 0 || proof.Start() >= proof.End() {
 		return false, fmt.Errorf("proof range [proof.start=%d, proof.end=%d) is not valid: %w", proof.Start(), proof.End(), ErrInvalidRange)
@@ -544,18 +704,18 @@ func (proof Proof) VerifySubtreeRootInclusion(nth *NmtHasher, subtreeRoots [][]b
 
 	// check that the root is valid w.r.t the NMT hasher
 	if err := nth.ValidateNodeFormat(root); err != nil {
-		return false, fmt.Errorf("root does not match the NMT hasher's hash format: %w", err)
+		return false, fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 	}
 	// check that all the proof.Notes() are valid w.r.t the NMT hasher
 	for _, node := range proof.Nodes() {
 		if err := nth.ValidateNodeFormat(node); err != nil {
-			return false, fmt.Errorf("proof nodes do not match the NMT hasher's hash format: %w", err)
+			return false, fmt.Errorf("%w: proof nodes do not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 		}
 	}
 	// check that all the subtree roots are valid w.r.t the NMT hasher
 	for _, subtreeRoot := range subtreeRoots {
 		if err := nth.ValidateNodeFormat(subtreeRoot); err != nil {
-			return false, fmt.Errorf("inner nodes does not match the NMT hasher's hash format: %w", err)
+			return false, fmt.Errorf("%w: inner nodes does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
 		}
 	}
 
@@ -656,6 +816,116 @@ func (proof Proof) VerifySubtreeRootInclusion(nth *NmtHasher, subtreeRoots [][]b
 	return bytes.Equal(rootHash, root), nil
 }
 
+// VerifySubtreeRoots verifies that the subtree roots, which cover the leaves in
+// the given ranges, are included in the tree of treeSize leaves whose root is
+// root. Unlike VerifySubtreeRootInclusion, it works for trees of any size and
+// any decomposition of the proof range into subtrees: the ranges must be
+// sorted, cover exactly the range of the proof, and each of them must be the
+// range of a node of the tree, i.e., a leaf, an inner node or the root.
+// It returns true or false accordingly, and an error if the inputs are
+// malformed.
+func (proof Proof) VerifySubtreeRoots(nth ValidatingHasher, subtreeRoots [][]byte, ranges []LeafRange, treeSize int, root []byte) (bool, error) {
+	if proof.Start() < 0 || proof.Start() >= proof.End() || proof.End() > treeSize {
+		return false, fmt.Errorf("proof range [proof.start=%d, proof.end=%d) is not valid for a tree of size %d: %w", proof.Start(), proof.End(), treeSize, ErrInvalidRange)
+	}
+	if len(subtreeRoots) != len(ranges) {
+		return false, fmt.Errorf("number of subtree roots %d is different than the number of leaf ranges %d", len(subtreeRoots), len(ranges))
+	}
+	next := proof.Start()
+	for _, r := range ranges {
+		if r.Start != next {
+			return false, fmt.Errorf("%w: leaf ranges do not cover the proof range contiguously at leaf %d", ErrInvalidRange, next)
+		}
+		if !isTreeNode(r, treeSize) {
+			return false, fmt.Errorf("%w: [%d, %d) is not the range of a node of a tree of size %d", ErrInvalidRange, r.Start, r.End, treeSize)
+		}
+		next = r.End
+	}
+	if next != proof.End() {
+		return false, fmt.Errorf("%w: leaf ranges end at leaf %d instead of %d", ErrInvalidRange, next, proof.End())
+	}
+
+	// check that the root, the proof nodes and the subtree roots are valid
+	// w.r.t the NMT hasher
+	if err := nth.ValidateNodeFormat(root); err != nil {
+		return false, fmt.Errorf("%w: root does not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+	}
+	for _, node := range proof.Nodes() {
+		if err := nth.ValidateNodeFormat(node); err != nil {
+			return false, fmt.Errorf("%w: proof nodes do not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+		}
+	}
+	for _, subtreeRoot := range subtreeRoots {
+		if err := nth.ValidateNodeFormat(subtreeRoot); err != nil {
+			return false, fmt.Errorf("%w: subtree roots do not match the NMT hasher's hash format: %w", ErrInvalidNodeFormat, err)
+		}
+	}
+
+	nodes := proof.Nodes()
+	var computeRoot func(start, end int) ([]byte, error)
+	computeRoot = func(start, end int) ([]byte, error) {
+		// if the current range does not overlap with the proof range, it is
+		// the root of a subtree included in the proof
+		if end <= proof.Start() || start >= proof.End() {
+			if len(nodes) == 0 {
+				return nil, fmt.Errorf("%w: missing node for subtree [%d, %d)", ErrInvalidNodeCount, start, end)
+			}
+			return popIfNonEmpty(&nodes), nil
+		}
+		if ranges[0].Start == start && ranges[0].End == end {
+			ranges = ranges[1:]
+			return popIfNonEmpty(&subtreeRoots), nil
+		}
+
+		k := getSplitPoint(end - start)
+		left, err := computeRoot(start, start+k)
+		if err != nil {
+			return nil, err
+		}
+		right, err := computeRoot(start+k, end)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := nth.HashNode(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash node: %w", err)
+		}
+		return hash, nil
+	}
+	rootHash, err := computeRoot(0, treeSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to compute root [%d, %d): %w", 0, treeSize, err)
+	}
+	if len(nodes) != 0 {
+		return false, fmt.Errorf("%w: %d unused proof nodes", ErrInvalidNodeCount, len(nodes))
+	}
+	return bytes.Equal(rootHash, root), nil
+}
+
+// isTreeNode reports whether r is the range of a node of a tree of size
+// treeSize. The nodes are the aligned subtrees whose size is a power of two,
+// as checked by nextSubtreeSize, and the nodes on the right-most branch of the
+// tree, whose sizes are arbitrary.
+func isTreeNode(r LeafRange, treeSize int) bool {
+	if r.Start < 0 || r.Start >= r.End || r.End > treeSize {
+		return false
+	}
+	if nextSubtreeSize(uint64(r.Start), uint64(r.End)) == r.End-r.Start {
+		return true
+	}
+	if r.End != treeSize {
+		return false
+	}
+	// descend the right-most branch of the tree
+	for start := 0; start < r.Start; {
+		start += getSplitPoint(treeSize - start)
+		if start == r.Start {
+			return true
+		}
+	}
+	return r.Start == 0
+}
+
 // ToLeafRanges returns the leaf ranges corresponding to the provided subtree roots.
 // The proof range defined by proofStart and proofEnd is end exclusive.
 // It uses the subtree root width to calculate the maximum number of leaves a subtree root can
@@ -739,7 +1009,21 @@ func largestPowerOfTwo(bound uint) (int, error) {
 	return 1 << (bits.Len(bound) - 1), nil
 }
 
-// ProtoToProof creates a proof from its proto representation.
+// ProofToProto converts a Proof to its protobuf representation, which shares
+// the nodes and the leaf hash of the proof, see ProtoToProof.
+func ProofToProto(proof Proof) pb.Proof {
+	return pb.Proof{
+		Start:                 int64(proof.start),
+		End:                   int64(proof.end),
+		Nodes:                 proof.nodes,
+		LeafHash:              proof.leafHash,
+		IsMaxNamespaceIgnored: proof.isMaxNamespaceIDIgnored,
+		TreeSize:              int64(proof.treeSize),
+	}
+}
+
+// ProtoToProof creates a proof from its proto representation. It does not
+// validate the proof, see ProtoToProofStrict.
 func ProtoToProof(protoProof pb.Proof) Proof {
 	if protoProof.Start == 0 && protoProof.End == 0 {
 		return NewEmptyRangeProof(protoProof.IsMaxNamespaceIgnored)
@@ -752,7 +1036,7 @@ func ProtoToProof(protoProof pb.Proof) Proof {
 			protoProof.Nodes,
 			protoProof.LeafHash,
 			protoProof.IsMaxNamespaceIgnored,
-		)
+		).WithTreeSize(int(protoProof.TreeSize))
 	}
 
 	return NewInclusionProof(
@@ -760,7 +1044,49 @@ func ProtoToProof(protoProof pb.Proof) Proof {
 		int(protoProof.End),
 		protoProof.Nodes,
 		protoProof.IsMaxNamespaceIgnored,
-	)
+	).WithTreeSize(int(protoProof.TreeSize))
+}
+
+// ProtoToProofStrict is like ProtoToProof, but returns an error instead of a
+// proof that can not have been generated by a tree:
+//   - ErrInvalidRange: the start, the end or the tree size is negative, the
+//     range is not within the tree, an empty range does not start at 0, or
+//     an absence proof does not cover a single leaf.
+//   - ErrInvalidNodeFormat: the nodes and the leaf hash are not all of the
+//     same non-zero length.
+//
+// An empty range proof must not carry nodes nor a leaf hash, in which case
+// ErrInvalidRange is returned as well, see VerifyNamespaceErr.
+func ProtoToProofStrict(protoProof pb.Proof) (Proof, error) {
+	start, end, treeSize := protoProof.Start, protoProof.End, protoProof.TreeSize
+	if start < 0 || end < 0 || treeSize < 0 {
+		return Proof{}, fmt.Errorf("%w: negative start %d, end %d or tree size %d", ErrInvalidRange, start, end, treeSize)
+	}
+	if start > end || end > math.MaxInt || treeSize > math.MaxInt || (treeSize > 0 && end > treeSize) {
+		return Proof{}, fmt.Errorf("%w: range [%d, %d) of a tree of size %d", ErrInvalidRange, start, end, treeSize)
+	}
+	if start == end {
+		if start != 0 {
+			return Proof{}, fmt.Errorf("%w: empty range starting at %d", ErrInvalidRange, start)
+		}
+		if len(protoProof.Nodes) != 0 || len(protoProof.LeafHash) != 0 {
+			return Proof{}, fmt.Errorf("%w: empty proof range with nodes or leaf hash", ErrInvalidRange)
+		}
+	}
+	if len(protoProof.LeafHash) != 0 && end-start != 1 {
+		return Proof{}, fmt.Errorf("%w: absence proof of range [%d, %d)", ErrInvalidRange, start, end)
+	}
+
+	nodeLen := len(protoProof.LeafHash)
+	for i, node := range protoProof.Nodes {
+		if nodeLen == 0 {
+			nodeLen = len(node)
+		}
+		if len(node) == 0 || len(node) != nodeLen {
+			return Proof{}, fmt.Errorf("%w: node %d is %d bytes long, expected %d", ErrInvalidNodeFormat, i, len(node), nodeLen)
+		}
+	}
+	return ProtoToProof(protoProof), nil
 }
 
 // nextSubtreeSize returns the number of leaves of the subtree adjacent to start
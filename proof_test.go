@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"hash"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -1147,6 +1148,76 @@ func Test_ProtoToProof(t *testing.T) {
 	}
 }
 
+func TestProof_Marshal(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3, 3, 5, 6, 7, 8)
+	inclusion, err := tree.ProveNamespace(namespace.ID{3})
+	require.NoError(t, err)
+	absence, err := tree.ProveNamespace(namespace.ID{4})
+	require.NoError(t, err)
+	require.True(t, absence.IsOfAbsence())
+
+	tests := []struct {
+		name  string
+		proof Proof
+	}{
+		{"inclusion proof", inclusion},
+		{"absence proof", absence},
+		{"proof without tree size", inclusion.WithTreeSize(0)},
+		{"proof of all the leaves", NewInclusionProof(0, 8, nil, true).WithTreeSize(8)},
+		{"empty proof", NewEmptyRangeProof(false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.proof.Marshal()
+			require.NoError(t, err)
+			var decoded Proof
+			require.NoError(t, decoded.Unmarshal(data))
+			assert.Equal(t, tt.proof, decoded)
+
+			pbProof := ProofToProto(tt.proof)
+			assert.Equal(t, tt.proof, ProtoToProof(pbProof))
+			strict, err := ProtoToProofStrict(pbProof)
+			require.NoError(t, err)
+			assert.Equal(t, tt.proof, strict)
+		})
+	}
+	var decoded Proof
+	assert.Error(t, decoded.Unmarshal([]byte{0xff}))
+}
+
+func TestProtoToProofStrict_Errors(t *testing.T) {
+	node := bytes.Repeat([]byte{1}, 34)
+	tests := []struct {
+		name       string
+		protoProof pb.Proof
+		wantErr    error
+	}{
+		{"negative start", pb.Proof{Start: -1, End: 1, Nodes: [][]byte{node}}, ErrInvalidRange},
+		{"negative end", pb.Proof{Start: -2, End: -1, Nodes: [][]byte{node}}, ErrInvalidRange},
+		{"negative tree size", pb.Proof{Start: 0, End: 1, Nodes: [][]byte{node}, TreeSize: -1}, ErrInvalidRange},
+		{"start after end", pb.Proof{Start: 2, End: 1, Nodes: [][]byte{node}}, ErrInvalidRange},
+		{"range beyond the tree", pb.Proof{Start: 1, End: 3, Nodes: [][]byte{node}, TreeSize: 2}, ErrInvalidRange},
+		{"empty range with nodes", pb.Proof{Nodes: [][]byte{node}}, ErrInvalidRange},
+		{"empty range with leaf hash", pb.Proof{LeafHash: node}, ErrInvalidRange},
+		{"empty range not at 0", pb.Proof{Start: 3, End: 3}, ErrInvalidRange},
+		{"absence proof of several leaves", pb.Proof{Start: 1, End: 3, Nodes: [][]byte{node}, LeafHash: node}, ErrInvalidRange},
+		{"nodes of different lengths", pb.Proof{Start: 0, End: 1, Nodes: [][]byte{node, node[1:]}}, ErrInvalidNodeFormat},
+		{"empty node", pb.Proof{Start: 0, End: 1, Nodes: [][]byte{{}}}, ErrInvalidNodeFormat},
+		{"leaf hash of a different length", pb.Proof{Start: 0, End: 1, Nodes: [][]byte{node}, LeafHash: node[1:]}, ErrInvalidNodeFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ProtoToProofStrict(tt.protoProof)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			data, err := tt.protoProof.Marshal()
+			require.NoError(t, err)
+			var decoded Proof
+			assert.ErrorIs(t, decoded.Unmarshal(data), tt.wantErr)
+		})
+	}
+}
+
 func TestLargestPowerOfTwo(t *testing.T) {
 	tests := []struct {
 		bound       uint
@@ -1928,6 +1999,126 @@ func TestVerifySubtreeRootInclusion_allocationBound(t *testing.T) {
 	require.Greater(t, len(ranges), len(subtreeRoots))
 }
 
+func TestVerifySubtreeRoots(t *testing.T) {
+	nth := NewNmtHasher(sha256.New(), 1, true)
+	for size := 1; size <= 20; size++ {
+		nIDs := make([]byte, size)
+		for i := range nIDs {
+			nIDs[i] = byte(i)
+		}
+		tree := exampleNMT(1, true, nIDs...)
+		root, err := tree.Root()
+		require.NoError(t, err)
+
+		for start := 0; start < size; start++ {
+			for end := start + 1; end <= size; end++ {
+				proof, err := tree.ProveRange(start, end)
+				require.NoError(t, err)
+
+				// the maximal subtrees covering the range, and the leaves
+				var leafRanges []LeafRange
+				for i := start; i < end; i++ {
+					leafRanges = append(leafRanges, LeafRange{Start: i, End: i + 1})
+				}
+				for _, ranges := range [][]LeafRange{subtreeCover(0, size, start, end), leafRanges} {
+					subtreeRoots := make([][]byte, len(ranges))
+					for i, r := range ranges {
+						subtreeRoots[i], err = tree.nodeHash(r.Start, r.End)
+						require.NoError(t, err)
+					}
+					ok, err := proof.VerifySubtreeRoots(nth, subtreeRoots, ranges, size, root)
+					require.NoError(t, err)
+					assert.True(t, ok, "size %d, ranges %v", size, ranges)
+				}
+			}
+		}
+	}
+}
+
+// subtreeCover returns the ranges of the maximal subtrees of the tree of the
+// leaves in [treeStart, treeEnd) that cover the leaves in [start, end).
+func subtreeCover(treeStart, treeEnd, start, end int) []LeafRange {
+	if end <= treeStart || start >= treeEnd {
+		return nil
+	}
+	if start <= treeStart && treeEnd <= end {
+		return []LeafRange{{Start: treeStart, End: treeEnd}}
+	}
+	k := getSplitPoint(treeEnd - treeStart)
+	return append(subtreeCover(treeStart, treeStart+k, start, end), subtreeCover(treeStart+k, treeEnd, start, end)...)
+}
+
+func TestVerifySubtreeRoots_False(t *testing.T) {
+	nth := NewNmtHasher(sha256.New(), 1, true)
+	tree := exampleNMT(1, true, 0, 1, 2, 3, 4, 5, 6)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	otherRoot, err := exampleNMT(1, true, 0, 1, 2, 3, 4, 5, 7).Root()
+	require.NoError(t, err)
+
+	// [4, 7) is a node on the right-most branch of the tree
+	proof, err := tree.ProveRange(4, 7)
+	require.NoError(t, err)
+	subtreeRoot, err := tree.nodeHash(4, 7)
+	require.NoError(t, err)
+	leafRoots := [][]byte{tree.leafHashes[4], tree.leafHashes[5], tree.leafHashes[6]}
+	leafRanges := []LeafRange{{Start: 4, End: 5}, {Start: 5, End: 6}, {Start: 6, End: 7}}
+
+	tests := []struct {
+		name         string
+		proof        Proof
+		subtreeRoots [][]byte
+		ranges       []LeafRange
+		treeSize     int
+		root         []byte
+		wantErr      error
+	}{
+		{"wrong root", proof, [][]byte{subtreeRoot}, []LeafRange{{Start: 4, End: 7}}, 7, otherRoot, nil},
+		{"wrong subtree root", proof, [][]byte{leafRoots[0]}, []LeafRange{{Start: 4, End: 7}}, 7, root, nil},
+		{"swapped subtree roots", proof, [][]byte{leafRoots[1], leafRoots[0], leafRoots[2]}, leafRanges, 7, root, nil},
+		{"not a node", proof, [][]byte{leafRoots[0], subtreeRoot}, []LeafRange{{Start: 4, End: 5}, {Start: 5, End: 7}}, 7, root, ErrInvalidRange},
+		{"not a node of a larger tree", proof, [][]byte{subtreeRoot}, []LeafRange{{Start: 4, End: 7}}, 8, root, ErrInvalidRange},
+		{"gap in ranges", proof, [][]byte{leafRoots[0], leafRoots[2]}, []LeafRange{leafRanges[0], leafRanges[2]}, 7, root, ErrInvalidRange},
+		{"ranges shorter than proof", proof, leafRoots[:2], leafRanges[:2], 7, root, ErrInvalidRange},
+		{"proof beyond tree size", proof, [][]byte{subtreeRoot}, []LeafRange{{Start: 4, End: 7}}, 6, root, ErrInvalidRange},
+		{"mismatching number of roots", proof, leafRoots[:2], leafRanges, 7, root, nil},
+		{"malformed subtree root", proof, [][]byte{subtreeRoot[:1]}, []LeafRange{{Start: 4, End: 7}}, 7, root, ErrInvalidNodeFormat},
+		{"missing proof node", NewInclusionProof(4, 7, nil, true), [][]byte{subtreeRoot}, []LeafRange{{Start: 4, End: 7}}, 7, root, ErrInvalidNodeCount},
+		{"extra proof node", NewInclusionProof(4, 7, append(proof.Nodes(), subtreeRoot), true), [][]byte{subtreeRoot}, []LeafRange{{Start: 4, End: 7}}, 7, root, ErrInvalidNodeCount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := tt.proof.VerifySubtreeRoots(nth, tt.subtreeRoots, tt.ranges, tt.treeSize, tt.root)
+			assert.False(t, ok)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsTreeNode(t *testing.T) {
+	for size := 1; size <= 33; size++ {
+		nodes := make(map[LeafRange]bool)
+		var collect func(start, end int)
+		collect = func(start, end int) {
+			nodes[LeafRange{Start: start, End: end}] = true
+			if end-start > 1 {
+				k := getSplitPoint(end - start)
+				collect(start, start+k)
+				collect(start+k, end)
+			}
+		}
+		collect(0, size)
+		for start := 0; start < size; start++ {
+			for end := start + 1; end <= size; end++ {
+				r := LeafRange{Start: start, End: end}
+				assert.Equal(t, nodes[r], isTreeNode(r, size), "range %v, size %d", r, size)
+			}
+		}
+	}
+}
+
 func TestComputeRootWithBasicValidation(t *testing.T) {
 	tree := exampleNMT(1, true, 1, 2, 3, 3, 3, 4, 5, 6, 7, 8)
 	hasher := tree.treeHasher.(*NmtHasher)
@@ -2049,3 +2240,293 @@ func TestComputePrefixedLeafHashes_EmptyInput(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, hashes)
 }
+
+func TestVerifyNamespaceRangeErr(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 1, 2, 4, 4, 6, 8, 8)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	lo, hi := namespace.ID{2}, namespace.ID{4}
+	proof, err := tree.ProveNamespaceRange(lo, hi)
+	require.NoError(t, err)
+	leaves := tree.leaves[2:5]
+	require.True(t, proof.VerifyNamespaceRange(sha256.New(), lo, hi, leaves, root))
+
+	absenceProof, err := tree.ProveNamespaceRange(namespace.ID{5}, namespace.ID{5})
+	require.NoError(t, err)
+	require.True(t, absenceProof.VerifyNamespaceRange(sha256.New(), namespace.ID{5}, namespace.ID{5}, nil, root))
+
+	otherRoot, err := exampleNMT(1, true, 1, 1, 2, 4, 4, 6, 8, 9).Root()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		proof   Proof
+		lo, hi  namespace.ID
+		leaves  [][]byte
+		root    []byte
+		wantErr error
+	}{
+		{"wider interval", proof, lo, namespace.ID{6}, leaves, root, ErrFailedCompletenessCheck},
+		{"wider interval on the left", proof, namespace.ID{1}, hi, leaves, root, ErrFailedCompletenessCheck},
+		{"narrower interval", proof, lo, namespace.ID{3}, leaves, root, ErrNamespaceMismatch},
+		{"inverted interval", proof, hi, lo, leaves, root, ErrInvalidRange},
+		{"unordered leaves", proof, lo, hi, [][]byte{leaves[1], leaves[0], leaves[2]}, root, ErrNamespaceMismatch},
+		{"missing leaf", proof, lo, hi, leaves[:2], root, ErrWrongLeafHashesSize},
+		{"leaf outside the interval", NewInclusionProof(1, 4, proof.Nodes(), true), lo, hi, tree.leaves[1:4], root, ErrNamespaceMismatch},
+		{"absence proof with leaf in the interval", absenceProof, namespace.ID{5}, namespace.ID{6}, nil, root, ErrNamespaceMismatch},
+		{"absence proof with leaves", absenceProof, namespace.ID{5}, namespace.ID{5}, tree.leaves[5:6], root, ErrWrongLeafHashesSize},
+		{"empty proof within the tree range", NewEmptyRangeProof(true), lo, hi, nil, root, ErrFailedCompletenessCheck},
+		{"invalid root", proof, lo, hi, leaves, root[1:], ErrInvalidNodeFormat},
+		{"other root", proof, lo, hi, leaves, otherRoot, ErrRootMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.False(t, tt.proof.VerifyNamespaceRange(sha256.New(), tt.lo, tt.hi, tt.leaves, tt.root))
+			err := tt.proof.VerifyNamespaceRangeErr(sha256.New(), tt.lo, tt.hi, tt.leaves, tt.root)
+			assert.ErrorIs(t, err, tt.wantErr, "%v", err)
+		})
+	}
+}
+
+func TestVerifyNamespaceErr(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3, 3, 4, 5, 6, 7)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	otherRoot, err := exampleNMT(1, true, 1, 2, 3, 3, 4, 5, 6, 8).Root()
+	require.NoError(t, err)
+
+	nID := namespace.ID{3}
+	proof, err := tree.ProveNamespace(nID)
+	require.NoError(t, err)
+	leaves := tree.Get(nID)
+
+	partialProof, err := tree.ProveRange(2, 3)
+	require.NoError(t, err)
+
+	corruptNodes := make([][]byte, len(proof.Nodes()))
+	copy(corruptNodes, proof.Nodes())
+	corruptNodes[0] = corruptNodes[0][:1]
+
+	wrongLeaf := append([]byte{4}, leaves[1][1:]...)
+
+	tests := []struct {
+		name    string
+		proof   Proof
+		leaves  [][]byte
+		root    []byte
+		wantErr error
+	}{
+		{"valid proof", proof, leaves, root, nil},
+		{"wrong root", proof, leaves, otherRoot, ErrRootMismatch},
+		{"malformed root", proof, leaves, root[:1], ErrInvalidNodeFormat},
+		{"malformed proof node", NewInclusionProof(2, 4, corruptNodes, true), leaves, root, ErrInvalidNodeFormat},
+		{"leaf of another namespace", proof, [][]byte{leaves[0], wrongLeaf}, root, ErrNamespaceMismatch},
+		{"leaf without namespace", proof, [][]byte{leaves[0], {}}, root, ErrInvalidLeafLen},
+		{"missing leaves", proof, leaves[:1], root, ErrWrongLeafHashesSize},
+		{"incomplete proof", partialProof, leaves[:1], root, ErrFailedCompletenessCheck},
+		{"empty proof within the root range", NewEmptyRangeProof(true), nil, root, ErrFailedCompletenessCheck},
+		{"empty proof with leaves", NewEmptyRangeProof(true), leaves, root, ErrWrongLeafHashesSize},
+		{"empty range with nodes", NewInclusionProof(2, 2, proof.Nodes(), true), nil, root, ErrInvalidRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.proof.VerifyNamespaceErr(sha256.New(), nID, tt.leaves, tt.root)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantErr == nil, tt.proof.VerifyNamespace(sha256.New(), nID, tt.leaves, tt.root))
+		})
+	}
+}
+
+func TestVerifyInclusionErr(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3, 3, 4, 5, 6, 7)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	otherRoot, err := exampleNMT(1, true, 1, 2, 3, 3, 4, 5, 6, 8).Root()
+	require.NoError(t, err)
+
+	nID := namespace.ID{3}
+	proof, err := tree.ProveRange(2, 3)
+	require.NoError(t, err)
+	leaves := [][]byte{tree.leaves[2][1:]}
+
+	tests := []struct {
+		name    string
+		proof   Proof
+		leaves  [][]byte
+		root    []byte
+		wantErr error
+	}{
+		{"valid proof", proof, leaves, root, nil},
+		{"wrong root", proof, leaves, otherRoot, ErrRootMismatch},
+		{"malformed root", proof, leaves, root[:1], ErrInvalidNodeFormat},
+		{"missing proof nodes", NewInclusionProof(2, 3, nil, true), leaves, root, ErrInvalidNodeCount},
+		{"missing leaves", proof, nil, root, ErrWrongLeafHashesSize},
+		{"invalid range", NewInclusionProof(3, 2, proof.Nodes(), true), leaves, root, ErrInvalidRange},
+		{"empty proof", NewEmptyRangeProof(true), nil, root, nil},
+		{"empty proof with leaves", NewEmptyRangeProof(true), leaves, root, ErrWrongLeafHashesSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.proof.VerifyInclusionErr(sha256.New(), nID, tt.leaves, tt.root)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantErr == nil, tt.proof.VerifyInclusion(sha256.New(), nID, tt.leaves, tt.root))
+		})
+	}
+}
+
+// reversedDigestHasher is a custom hasher whose namespaced hashes have the
+// digest of the NmtHasher reversed.
+type reversedDigestHasher struct {
+	*NmtHasher
+}
+
+func (h reversedDigestHasher) reverse(hash []byte) []byte {
+	hash = slices.Clone(hash)
+	slices.Reverse(hash[2*h.NamespaceLen:])
+	return hash
+}
+
+func (h reversedDigestHasher) HashLeaf(data []byte) ([]byte, error) {
+	hash, err := h.NmtHasher.HashLeaf(data)
+	if err != nil {
+		return nil, err
+	}
+	return h.reverse(hash), nil
+}
+
+func (h reversedDigestHasher) HashNode(left, right []byte) ([]byte, error) {
+	hash, err := h.NmtHasher.HashNode(left, right)
+	if err != nil {
+		return nil, err
+	}
+	return h.reverse(hash), nil
+}
+
+func TestVerifyWithHasher_CustomHasher(t *testing.T) {
+	nth := reversedDigestHasher{NewNmtHasher(sha256.New(), 1, true)}
+	tree := New(sha256.New(), NamespaceIDSize(1), CustomHasher(nth))
+	defaultTree := exampleNMT(1, true, 1, 2, 3, 3, 5, 6, 7, 8)
+	for _, leaf := range defaultTree.leaves {
+		require.NoError(t, tree.Push(leaf))
+	}
+	root, err := tree.Root()
+	require.NoError(t, err)
+	defaultRoot, err := defaultTree.Root()
+	require.NoError(t, err)
+	require.NotEqual(t, defaultRoot, root)
+
+	for _, nID := range []namespace.ID{{0}, {3}, {4}, {8}, {9}} {
+		proof, err := tree.ProveNamespace(nID)
+		require.NoError(t, err)
+		leaves := tree.Get(nID)
+		require.NoError(t, proof.VerifyNamespaceWithHasher(nth, nID, leaves, root), "namespace %x", nID)
+		if !proof.IsEmptyProof() {
+			// the proof does not hold for the default hasher
+			assert.False(t, proof.VerifyNamespace(sha256.New(), nID, leaves, root))
+		}
+	}
+
+	proof, err := tree.ProveRange(2, 4)
+	require.NoError(t, err)
+	leaves := [][]byte{tree.leaves[2][1:], tree.leaves[3][1:]}
+	require.NoError(t, proof.VerifyInclusionWithHasher(nth, namespace.ID{3}, leaves, root))
+	assert.ErrorIs(t, proof.VerifyInclusionWithHasher(nth, namespace.ID{3}, leaves, defaultRoot), ErrRootMismatch)
+	assert.ErrorIs(t, proof.VerifyInclusionErr(sha256.New(), namespace.ID{3}, leaves, root), ErrRootMismatch)
+
+	subtreeRoot, err := tree.ComputeSubtreeRoot(2, 4)
+	require.NoError(t, err)
+	ok, err := proof.VerifySubtreeRootInclusion(nth, [][]byte{subtreeRoot}, 2, root)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	multiProof, err := tree.ProveIndices([]int{0, 5})
+	require.NoError(t, err)
+	ok, err = multiProof.VerifyLeafHashes(nth, [][]byte{tree.leafHashes[0], tree.leafHashes[5]}, root)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestProof_TreeSize(t *testing.T) {
+	for size := 1; size <= 20; size++ {
+		// all the leaves have the same namespace so that any range can be
+		// verified with VerifyInclusion
+		tree := exampleNMT(1, true, make([]byte, size)...)
+		root, err := tree.Root()
+		require.NoError(t, err)
+
+		for start := 0; start < size; start++ {
+			for end := start + 1; end <= size; end++ {
+				proof, err := tree.ProveRange(start, end)
+				require.NoError(t, err)
+				require.Equal(t, size, proof.TreeSize())
+				require.Len(t, proof.Nodes(), proofNodeCount(0, size, start, end))
+
+				leaves := make([][]byte, 0, end-start)
+				for _, leaf := range tree.leaves[start:end] {
+					leaves = append(leaves, leaf[1:])
+				}
+				// proofs without a tree size remain valid
+				assert.True(t, proof.VerifyInclusion(sha256.New(), namespace.ID{0}, leaves, root))
+				assert.True(t, proof.WithTreeSize(0).VerifyInclusion(sha256.New(), namespace.ID{0}, leaves, root))
+			}
+		}
+	}
+}
+
+func TestProof_TreeSize_False(t *testing.T) {
+	tree := exampleNMT(1, true, 0, 0, 0, 0, 0, 0)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	// the nodes of the proof are [0, 4) and [4, 5)
+	proof, err := tree.ProveRange(5, 6)
+	require.NoError(t, err)
+	leaves := [][]byte{tree.leaves[5][1:]}
+
+	tests := []struct {
+		name    string
+		proof   Proof
+		wantErr error
+	}{
+		{"tree size smaller than proof range", proof.WithTreeSize(5), ErrInvalidRange},
+		{"negative tree size", proof.WithTreeSize(-1), ErrInvalidRange},
+		{"larger tree size", proof.WithTreeSize(7), ErrInvalidNodeCount},
+		{"extra proof node", NewInclusionProof(5, 6, append(slices.Clone(proof.Nodes()), proof.Nodes()[1]), true).WithTreeSize(6), ErrInvalidNodeCount},
+		{"missing proof node", NewInclusionProof(5, 6, proof.Nodes()[:1], true).WithTreeSize(6), ErrInvalidNodeCount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.proof.VerifyInclusionErr(sha256.New(), namespace.ID{0}, leaves, root)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestProof_TreeSize_Encoding(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3, 4, 5)
+	proof, err := tree.ProveNamespace(namespace.ID{3})
+	require.NoError(t, err)
+	require.Equal(t, 5, proof.TreeSize())
+
+	data, err := proof.MarshalJSON()
+	require.NoError(t, err)
+	var decoded Proof
+	require.NoError(t, decoded.UnmarshalJSON(data))
+	assert.Equal(t, proof, decoded)
+
+	pbProof := pb.Proof{Start: int64(proof.Start()), End: int64(proof.End()), Nodes: proof.Nodes(), IsMaxNamespaceIgnored: true, TreeSize: 5}
+	assert.Equal(t, proof, ProtoToProof(pbProof))
+
+	// proofs serialized without a tree size decode to proofs without one
+	pbProof.TreeSize = 0
+	assert.Equal(t, 0, ProtoToProof(pbProof).TreeSize())
+	require.NoError(t, decoded.UnmarshalJSON([]byte(`{"start":2,"end":3,"is_max_namespace_ignored":true}`)))
+	assert.Equal(t, 0, decoded.TreeSize())
+}
@@ -0,0 +1,158 @@
+package nmt
+
+import (
+	"bytes"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// ReadOnlyTree is an immutable snapshot of a NamespacedMerkleTree, see
+// NamespacedMerkleTree.Freeze. All of its nodes are computed when it is
+// created and its methods only read them, so that they can be called from
+// many goroutines at once, e.g., to serve parallel proof requests for the same
+// tree. Its methods behave like the NamespacedMerkleTree methods of the same
+// name.
+type ReadOnlyTree struct {
+	tree *NamespacedMerkleTree
+}
+
+// Freeze returns a snapshot of the tree, which holds copies of its leaves and
+// of the namespaced hashes of all its nodes. The snapshot is not affected by
+// the later changes of the tree, including a Reset of a tree reusing its
+// buffers. Any error returned by this method is irrecoverable and indicates an
+// illegal state of the tree (n).
+func (n *NamespacedMerkleTree) Freeze() (*ReadOnlyTree, error) {
+	root, err := n.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	store := NewMemoryNodeStore()
+	var copyNodes func(start, end int) error
+	copyNodes = func(start, end int) error {
+		// the leaves are served from the leaf hashes
+		if end-start < 2 {
+			return nil
+		}
+		hash, err := n.nodeHash(start, end)
+		if err != nil {
+			return err
+		}
+		store.nodes[LeafRange{Start: start, End: end}] = bytes.Clone(hash)
+		k := getSplitPoint(end - start)
+		if err := copyNodes(start, start+k); err != nil {
+			return err
+		}
+		return copyNodes(start+k, end)
+	}
+	if err := copyNodes(0, n.Size()); err != nil {
+		return nil, err
+	}
+
+	var leaves [][]byte
+	if n.hasLeafData() {
+		leaves = cloneLeaves(n.leaves)
+	}
+	return &ReadOnlyTree{tree: &NamespacedMerkleTree{
+		treeHasher:         n.treeHasher,
+		shortAbsenceProofs: n.shortAbsenceProofs,
+		store:              store,
+		storeTrusted:       true,
+		frozen:             true,
+		leaves:             leaves,
+		leafHashes:         cloneLeaves(n.leafHashes),
+		namespaces:         n.namespaces.clone(),
+		minNID:             bytes.Clone(n.minNID),
+		maxNID:             bytes.Clone(n.maxNID),
+		rawRoot:            bytes.Clone(root),
+	}}, nil
+}
+
+// cloneLeaves returns a deep copy of leaves, whose bytes share a single
+// allocation.
+func cloneLeaves(leaves [][]byte) [][]byte {
+	size := 0
+	for _, leaf := range leaves {
+		size += len(leaf)
+	}
+	buf := make([]byte, 0, size)
+	clones := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		buf = append(buf, leaf...)
+		clones[i] = buf[len(buf)-len(leaf) : len(buf) : len(buf)]
+	}
+	return clones
+}
+
+// Root returns the root of the tree.
+func (t *ReadOnlyTree) Root() ([]byte, error) {
+	return t.tree.Root()
+}
+
+// Size returns the number of leaves of the tree.
+func (t *ReadOnlyTree) Size() int {
+	return t.tree.Size()
+}
+
+// NamespaceSize returns the namespace size of the tree.
+func (t *ReadOnlyTree) NamespaceSize() namespace.IDSize {
+	return t.tree.NamespaceSize()
+}
+
+// Get returns the leaves of the namespace nID, which must not be modified. It
+// returns nil if the tree does not hold the leaves themselves, see
+// NewFromLeafHashes.
+func (t *ReadOnlyTree) Get(nID namespace.ID) [][]byte {
+	leaves := t.tree.Get(nID)
+	// appending to the leaves must not overwrite the following ones
+	return leaves[:len(leaves):len(leaves)]
+}
+
+// GetErr is like Get, but returns ErrLeafDataUnavailable if the tree does not
+// hold the leaves themselves.
+func (t *ReadOnlyTree) GetErr(nID namespace.ID) ([][]byte, error) {
+	if !t.tree.hasLeafData() {
+		return nil, ErrLeafDataUnavailable
+	}
+	return t.Get(nID), nil
+}
+
+// GetWithProof returns the leaves of the namespace nID together with their
+// proof, see NamespacedMerkleTree.GetWithProof.
+func (t *ReadOnlyTree) GetWithProof(nID namespace.ID) ([][]byte, Proof, error) {
+	if !t.tree.hasLeafData() {
+		return nil, Proof{}, ErrLeafDataUnavailable
+	}
+	proof, err := t.ProveNamespace(nID)
+	return t.Get(nID), proof, err
+}
+
+// Prove returns the inclusion proof of the leaf at index, see
+// NamespacedMerkleTree.Prove.
+func (t *ReadOnlyTree) Prove(index int) (Proof, error) {
+	return t.tree.Prove(index)
+}
+
+// ProveRange returns the inclusion proof of the leaves in [start, end), see
+// NamespacedMerkleTree.ProveRange.
+func (t *ReadOnlyTree) ProveRange(start, end int) (Proof, error) {
+	return t.tree.ProveRange(start, end)
+}
+
+// ProveIndices returns the inclusion proof of the leaves at indices, see
+// NamespacedMerkleTree.ProveIndices.
+func (t *ReadOnlyTree) ProveIndices(indices []int) (MultiProof, error) {
+	return t.tree.ProveIndices(indices)
+}
+
+// ProveNamespace returns the proof of the namespace nID, see
+// NamespacedMerkleTree.ProveNamespace.
+func (t *ReadOnlyTree) ProveNamespace(nID namespace.ID) (Proof, error) {
+	return t.tree.ProveNamespace(nID)
+}
+
+// ProveNamespaceRange returns the proof of the namespaces in [lo, hi], see
+// NamespacedMerkleTree.ProveNamespaceRange.
+func (t *ReadOnlyTree) ProveNamespaceRange(lo, hi namespace.ID) (Proof, error) {
+	return t.tree.ProveNamespaceRange(lo, hi)
+}
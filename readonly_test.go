@@ -0,0 +1,158 @@
+package nmt
+
+import (
+	"crypto/sha256"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+func TestFreeze(t *testing.T) {
+	for _, size := range []int{0, 1, 5, 8, 13} {
+		nIDs := make([]byte, size)
+		for i := range nIDs {
+			nIDs[i] = byte(2 * (i / 2))
+		}
+		tree := exampleNMT(1, true, nIDs...)
+		frozen, err := tree.Freeze()
+		require.NoError(t, err)
+
+		root, err := tree.Root()
+		require.NoError(t, err)
+		gotRoot, err := frozen.Root()
+		require.NoError(t, err)
+		assert.Equal(t, root, gotRoot)
+		assert.Equal(t, tree.Size(), frozen.Size())
+		assert.Equal(t, tree.NamespaceSize(), frozen.NamespaceSize())
+
+		for nID := byte(0); nID <= byte(size+1); nID++ {
+			want, err := tree.ProveNamespace(namespace.ID{nID})
+			require.NoError(t, err)
+			got, err := frozen.ProveNamespace(namespace.ID{nID})
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "size %d, namespace %d", size, nID)
+			assert.Equal(t, tree.Get(namespace.ID{nID}), frozen.Get(namespace.ID{nID}))
+
+			wantRange, err := tree.ProveNamespaceRange(namespace.ID{nID}, namespace.ID{nID + 1})
+			require.NoError(t, err)
+			gotRange, err := frozen.ProveNamespaceRange(namespace.ID{nID}, namespace.ID{nID + 1})
+			require.NoError(t, err)
+			assert.Equal(t, wantRange, gotRange)
+		}
+		for start := 0; start < size; start++ {
+			for end := start + 1; end <= size; end++ {
+				want, err := tree.ProveRange(start, end)
+				require.NoError(t, err)
+				got, err := frozen.ProveRange(start, end)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, "size %d, range [%d, %d)", size, start, end)
+			}
+		}
+		if size > 1 {
+			want, err := tree.ProveIndices([]int{0, size - 1})
+			require.NoError(t, err)
+			got, err := frozen.ProveIndices([]int{0, size - 1})
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+		_, err = frozen.ProveRange(0, size+1)
+		assert.ErrorIs(t, err, ErrInvalidRange)
+	}
+}
+
+func TestFreeze_Snapshot(t *testing.T) {
+	tree := New(sha256.New(), NamespaceIDSize(1), ReuseBuffers(true))
+	leaves := make([][]byte, 6)
+	for i := range leaves {
+		leaves[i] = append([]byte{byte(i / 2)}, "leaf"...)
+		require.NoError(t, tree.Push(leaves[i]))
+	}
+	root, err := tree.Root()
+	require.NoError(t, err)
+	root = append([]byte(nil), root...)
+	want, err := tree.ProveNamespace(namespace.ID{1})
+	require.NoError(t, err)
+
+	frozen, err := tree.Freeze()
+	require.NoError(t, err)
+
+	// neither pushing more leaves, nor resetting the tree and reusing the
+	// memory of its leaves affects the snapshot
+	require.NoError(t, tree.Push(append([]byte{3}, "leaf"...)))
+	tree.Reset()
+	for _, leaf := range leaves {
+		leaf[0] = 9
+	}
+
+	gotRoot, err := frozen.Root()
+	require.NoError(t, err)
+	assert.Equal(t, root, gotRoot)
+	leavesOf1, proof, err := frozen.GetWithProof(namespace.ID{1})
+	require.NoError(t, err)
+	assert.Equal(t, want, proof)
+	assert.Equal(t, [][]byte{append([]byte{1}, "leaf"...), append([]byte{1}, "leaf"...)}, leavesOf1)
+	assert.True(t, proof.VerifyNamespace(sha256.New(), namespace.ID{1}, leavesOf1, gotRoot))
+
+	// appending to the leaves of a namespace does not overwrite the next ones
+	_ = append(leavesOf1, []byte{0})
+	assert.Equal(t, []byte{2, 'l', 'e', 'a', 'f'}, frozen.Get(namespace.ID{2})[0])
+}
+
+func TestFreeze_FromLeafHashes(t *testing.T) {
+	tree := exampleNMT(1, true, 0, 1, 2, 3)
+	fromHashes, err := NewFromLeafHashes(tree.treeHasher, tree.leafHashes)
+	require.NoError(t, err)
+	frozen, err := fromHashes.Freeze()
+	require.NoError(t, err)
+	assert.Nil(t, frozen.Get(namespace.ID{1}))
+	_, err = frozen.GetErr(namespace.ID{1})
+	assert.ErrorIs(t, err, ErrLeafDataUnavailable)
+	_, _, err = frozen.GetWithProof(namespace.ID{1})
+	assert.ErrorIs(t, err, ErrLeafDataUnavailable)
+	want, err := tree.ProveNamespace(namespace.ID{1})
+	require.NoError(t, err)
+	got, err := frozen.ProveNamespace(namespace.ID{1})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// TestReadOnlyTree_Concurrent generates proofs from many goroutines at once;
+// run it with the -race flag to detect data races.
+func TestReadOnlyTree_Concurrent(t *testing.T) {
+	nIDs := make([]byte, 64)
+	for i := range nIDs {
+		nIDs[i] = byte(i / 4)
+	}
+	frozen, err := exampleNMT(1, true, nIDs...).Freeze()
+	require.NoError(t, err)
+	root, err := frozen.Root()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 64; i++ {
+				nID := namespace.ID{byte((g + i) % 20)}
+				leaves, proof, err := frozen.GetWithProof(nID)
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.True(t, proof.VerifyNamespace(sha256.New(), nID, leaves, root))
+
+				rangeProof, err := frozen.ProveRange(i, i+1)
+				if !assert.NoError(t, err) {
+					return
+				}
+				leaf := frozen.tree.leaves[i]
+				assert.True(t, rangeProof.VerifyInclusion(sha256.New(), leaf[:1], [][]byte{leaf[1:]}, root))
+			}
+		}()
+	}
+	wg.Wait()
+}
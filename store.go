@@ -0,0 +1,395 @@
+package nmt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// ErrNodeNotFound is returned by a NodeStore when the requested node is not
+// stored.
+var ErrNodeNotFound = errors.New("node not found")
+
+// NodeStore persists the namespaced hashes of the nodes of an NMT. A node is
+// addressed by its position in the tree, i.e., the range of leaves it covers,
+// and can also be looked up by its namespaced hash. A tree writes its leaves
+// to a store supplied through CustomNodeStore as nodes covering a single leaf,
+// i.e., [i, i+1), while the default store only holds the inner nodes.
+//
+// Implementations must be safe for concurrent use.
+type NodeStore interface {
+	// Put stores the namespaced hash of the node covering the leaves in the
+	// range r, replacing any node previously stored at that position. The
+	// store may retain the hash slice.
+	Put(r LeafRange, hash []byte) error
+	// Get returns the namespaced hash of the node covering the leaves in the
+	// range r. It returns ErrNodeNotFound if there is no such node.
+	Get(r LeafRange) ([]byte, error)
+	// GetByHash returns the range of leaves covered by the node with the
+	// given namespaced hash. It returns ErrNodeNotFound if there is no such
+	// node.
+	GetByHash(hash []byte) (LeafRange, error)
+	// Delete removes the node covering the leaves in the range r, if any.
+	Delete(r LeafRange) error
+	// Reset removes all the nodes from the store.
+	Reset() error
+}
+
+var (
+	_ NodeStore = (*MemoryNodeStore)(nil)
+	_ NodeStore = (*FileNodeStore)(nil)
+)
+
+// MemoryNodeStore is an in-memory NodeStore. It is the default store of a
+// NamespacedMerkleTree.
+type MemoryNodeStore struct {
+	mu    sync.RWMutex
+	nodes map[LeafRange][]byte
+	// byHash indexes the positions of the nodes by their hash. It is built
+	// lazily on the first GetByHash call and dropped on every write, so that
+	// trees that never look nodes up by hash do not pay for the index.
+	byHash map[string]LeafRange
+}
+
+// NewMemoryNodeStore returns an empty in-memory NodeStore.
+func NewMemoryNodeStore() *MemoryNodeStore {
+	return &MemoryNodeStore{nodes: make(map[LeafRange][]byte)}
+}
+
+func (s *MemoryNodeStore) Put(r LeafRange, hash []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[r] = hash
+	s.byHash = nil
+	return nil
+}
+
+func (s *MemoryNodeStore) Get(r LeafRange) ([]byte, error) {
+	hash, ok := s.lookup(r)
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	return hash, nil
+}
+
+// lookup returns the namespaced hash of the node covering the leaves in the
+// range r, and whether there is such a node.
+func (s *MemoryNodeStore) lookup(r LeafRange) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hash, ok := s.nodes[r]
+	return hash, ok
+}
+
+func (s *MemoryNodeStore) GetByHash(hash []byte) (LeafRange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.byHash == nil {
+		s.byHash = make(map[string]LeafRange, len(s.nodes))
+		for r, h := range s.nodes {
+			s.byHash[string(h)] = r
+		}
+	}
+	r, ok := s.byHash[string(hash)]
+	if !ok {
+		return LeafRange{}, fmt.Errorf("%w: %x", ErrNodeNotFound, hash)
+	}
+	return r, nil
+}
+
+func (s *MemoryNodeStore) Delete(r LeafRange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.nodes, r)
+	s.byHash = nil
+	return nil
+}
+
+func (s *MemoryNodeStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.nodes) > 0 {
+		clear(s.nodes)
+	}
+	s.byHash = nil
+	return nil
+}
+
+// Len returns the number of nodes in the store.
+func (s *MemoryNodeStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.nodes)
+}
+
+// fileRecordHeaderSize is the size of the header of a FileNodeStore record:
+// the start and end of the leaf range as uint64 followed by the length of the
+// hash as uint32, all big endian.
+const fileRecordHeaderSize = 8 + 8 + 4
+
+// fileCompactionMinSize is the size of the records of replaced or deleted
+// nodes below which a FileNodeStore is never compacted.
+const fileCompactionMinSize = 64 << 10
+
+// FileNodeStore is a simple file-backed NodeStore. Nodes are appended to the
+// file as records of the form start || end || len(hash) || hash, where a
+// record with an empty hash deletes the node at that position. An index of
+// the positions of the nodes is kept in memory and rebuilt from the file when
+// the store is opened, so that the nodes persisted by a tree can be used to
+// generate proofs later on, see NewFromNodeStore.
+// Whenever the records of replaced or deleted nodes take more space than those
+// of the stored nodes, the file is compacted, i.e., rewritten with only the
+// latter, so that it does not grow as the spine of a tree is recomputed after
+// every push.
+type FileNodeStore struct {
+	mu   sync.RWMutex
+	path string
+	file *os.File
+	// size is the size of the file, i.e., the offset of the next record
+	size int64
+	// live is the size of the records of the stored nodes
+	live int64
+	// index maps the position of a node to the offset of its hash in the file
+	index map[LeafRange]fileNodeLocation
+	// byHash indexes the positions of the nodes by their hash. It is built
+	// lazily on the first GetByHash call and dropped on every write.
+	byHash map[string]LeafRange
+}
+
+type fileNodeLocation struct {
+	offset int64
+	length uint32
+}
+
+// OpenFileNodeStore opens the FileNodeStore persisted at path, creating it if
+// it does not exist. It returns an error if the file is corrupted.
+func OpenFileNodeStore(path string) (*FileNodeStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileNodeStore{
+		path:  path,
+		file:  f,
+		index: make(map[LeafRange]fileNodeLocation),
+	}
+	if err := s.load(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to load node store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// load rebuilds the index of the store from its file.
+func (s *FileNodeStore) load() error {
+	r := bufio.NewReader(s.file)
+	header := make([]byte, fileRecordHeaderSize)
+	var offset int64
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("truncated record header at offset %d: %w", offset, err)
+		}
+		start := binary.BigEndian.Uint64(header[0:8])
+		end := binary.BigEndian.Uint64(header[8:16])
+		length := binary.BigEndian.Uint32(header[16:20])
+		if _, err := r.Discard(int(length)); err != nil {
+			return fmt.Errorf("truncated record at offset %d: %w", offset, err)
+		}
+		if start >= end || end > math.MaxInt {
+			return fmt.Errorf("invalid leaf range [%d, %d) at offset %d", start, end, offset)
+		}
+		pos := LeafRange{Start: int(start), End: int(end)}
+		if length == 0 {
+			delete(s.index, pos)
+		} else {
+			s.index[pos] = fileNodeLocation{offset: offset + fileRecordHeaderSize, length: length}
+		}
+		offset += fileRecordHeaderSize + int64(length)
+	}
+	s.size = offset
+	for _, loc := range s.index {
+		s.live += fileRecordHeaderSize + int64(loc.length)
+	}
+	return nil
+}
+
+// appendRecord appends the record of the node covering the leaves in the
+// range r to buf.
+func appendRecord(buf []byte, r LeafRange, hash []byte) []byte {
+	buf = binary.BigEndian.AppendUint64(buf, uint64(r.Start))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(r.End))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(hash)))
+	return append(buf, hash...)
+}
+
+// append writes a record for the node covering the leaves in the range r to
+// the end of the file.
+func (s *FileNodeStore) append(r LeafRange, hash []byte) error {
+	record := appendRecord(make([]byte, 0, fileRecordHeaderSize+len(hash)), r, hash)
+	if _, err := s.file.WriteAt(record, s.size); err != nil {
+		return err
+	}
+	s.size += int64(len(record))
+	s.byHash = nil
+	return nil
+}
+
+func (s *FileNodeStore) Put(r LeafRange, hash []byte) error {
+	if r.Start < 0 || r.Start >= r.End {
+		return fmt.Errorf("%w: [%d, %d)", ErrInvalidRange, r.Start, r.End)
+	}
+	if len(hash) == 0 {
+		return errors.New("cannot store an empty node hash")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset := s.size + fileRecordHeaderSize
+	if err := s.append(r, hash); err != nil {
+		return err
+	}
+	if old, ok := s.index[r]; ok {
+		s.live -= fileRecordHeaderSize + int64(old.length)
+	}
+	s.index[r] = fileNodeLocation{offset: offset, length: uint32(len(hash))}
+	s.live += fileRecordHeaderSize + int64(len(hash))
+	return s.compactIfWasteful()
+}
+
+func (s *FileNodeStore) Get(r LeafRange) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.get(r)
+}
+
+func (s *FileNodeStore) get(r LeafRange) ([]byte, error) {
+	loc, ok := s.index[r]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	hash := make([]byte, loc.length)
+	if _, err := s.file.ReadAt(hash, loc.offset); err != nil {
+		return nil, err
+	}
+	return hash, nil
+}
+
+func (s *FileNodeStore) GetByHash(hash []byte) (LeafRange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.byHash == nil {
+		byHash := make(map[string]LeafRange, len(s.index))
+		for r := range s.index {
+			h, err := s.get(r)
+			if err != nil {
+				return LeafRange{}, err
+			}
+			byHash[string(h)] = r
+		}
+		s.byHash = byHash
+	}
+	r, ok := s.byHash[string(hash)]
+	if !ok {
+		return LeafRange{}, fmt.Errorf("%w: %x", ErrNodeNotFound, hash)
+	}
+	return r, nil
+}
+
+func (s *FileNodeStore) Delete(r LeafRange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loc, ok := s.index[r]
+	if !ok {
+		return nil
+	}
+	if err := s.append(r, nil); err != nil {
+		return err
+	}
+	delete(s.index, r)
+	s.live -= fileRecordHeaderSize + int64(loc.length)
+	return s.compactIfWasteful()
+}
+
+// compactIfWasteful compacts the file if the records of the replaced or
+// deleted nodes take more space than those of the stored nodes.
+func (s *FileNodeStore) compactIfWasteful() error {
+	if wasted := s.size - s.live; wasted < fileCompactionMinSize || wasted <= s.live {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact rewrites the records of the stored nodes to a new file, which then
+// atomically replaces the file of the store.
+func (s *FileNodeStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	index := make(map[LeafRange]fileNodeLocation, len(s.index))
+	w := bufio.NewWriter(f)
+	var offset int64
+	var record []byte
+	for r := range s.index {
+		hash, err := s.get(r)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		record = appendRecord(record[:0], r, hash)
+		if _, err := w.Write(record); err != nil {
+			f.Close()
+			return err
+		}
+		index[r] = fileNodeLocation{offset: offset + fileRecordHeaderSize, length: uint32(len(hash))}
+		offset += int64(len(record))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		f.Close()
+		return err
+	}
+	s.file.Close()
+	s.file = f
+	s.size = offset
+	s.index = index
+	return nil
+}
+
+func (s *FileNodeStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Truncate(0); err != nil {
+		return err
+	}
+	s.size = 0
+	s.live = 0
+	s.byHash = nil
+	clear(s.index)
+	return nil
+}
+
+// Sync commits the content of the store to stable storage.
+func (s *FileNodeStore) Sync() error {
+	return s.file.Sync()
+}
+
+// Close closes the file of the store.
+func (s *FileNodeStore) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,171 @@
+package nmt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+func TestNodeStore(t *testing.T) {
+	stores := []struct {
+		name string
+		open func(t *testing.T) NodeStore
+	}{
+		{"memory", func(*testing.T) NodeStore { return NewMemoryNodeStore() }},
+		{"file", func(t *testing.T) NodeStore {
+			s, err := OpenFileNodeStore(filepath.Join(t.TempDir(), "nodes"))
+			require.NoError(t, err)
+			t.Cleanup(func() { s.Close() })
+			return s
+		}},
+	}
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.open(t)
+			r1, r2 := LeafRange{Start: 0, End: 1}, LeafRange{Start: 0, End: 2}
+
+			_, err := s.Get(r1)
+			assert.True(t, errors.Is(err, ErrNodeNotFound))
+			_, err = s.GetByHash([]byte("node"))
+			assert.True(t, errors.Is(err, ErrNodeNotFound))
+
+			require.NoError(t, s.Put(r1, []byte("leaf")))
+			require.NoError(t, s.Put(r2, []byte("node")))
+			got, err := s.Get(r1)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("leaf"), got)
+			r, err := s.GetByHash([]byte("node"))
+			require.NoError(t, err)
+			assert.Equal(t, r2, r)
+
+			// replace the node at r2
+			require.NoError(t, s.Put(r2, []byte("other node")))
+			got, err = s.Get(r2)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("other node"), got)
+			_, err = s.GetByHash([]byte("node"))
+			assert.True(t, errors.Is(err, ErrNodeNotFound))
+
+			require.NoError(t, s.Delete(r2))
+			_, err = s.Get(r2)
+			assert.True(t, errors.Is(err, ErrNodeNotFound))
+			// deleting a missing node is a no-op
+			require.NoError(t, s.Delete(r2))
+
+			require.NoError(t, s.Reset())
+			_, err = s.Get(r1)
+			assert.True(t, errors.Is(err, ErrNodeNotFound))
+		})
+	}
+}
+
+func TestFileNodeStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes")
+	s, err := OpenFileNodeStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Put(LeafRange{Start: 0, End: 1}, []byte("leaf 0")))
+	require.NoError(t, s.Put(LeafRange{Start: 1, End: 2}, []byte("leaf 1")))
+	require.NoError(t, s.Put(LeafRange{Start: 0, End: 1}, []byte("new leaf 0")))
+	require.NoError(t, s.Delete(LeafRange{Start: 1, End: 2}))
+	require.NoError(t, s.Close())
+
+	s, err = OpenFileNodeStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+	got, err := s.Get(LeafRange{Start: 0, End: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new leaf 0"), got)
+	_, err = s.Get(LeafRange{Start: 1, End: 2})
+	assert.True(t, errors.Is(err, ErrNodeNotFound))
+}
+
+func TestNewFromNodeStore(t *testing.T) {
+	const nidSize = 2
+	path := filepath.Join(t.TempDir(), "nodes")
+	store, err := OpenFileNodeStore(path)
+	require.NoError(t, err)
+
+	data, err := generateRandNamespacedRawData(13, nidSize, 16)
+	require.NoError(t, err)
+	persisted := New(sha256.New(), NamespaceIDSize(nidSize), CustomNodeStore(store))
+	tree := New(sha256.New(), NamespaceIDSize(nidSize))
+	for _, d := range data {
+		require.NoError(t, persisted.Push(d))
+		require.NoError(t, tree.Push(d))
+	}
+	_, err = persisted.Root()
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+	root, err := tree.Root()
+	require.NoError(t, err)
+
+	store, err = OpenFileNodeStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	restored, err := NewFromNodeStore(sha256.New(), store, len(data), NamespaceIDSize(nidSize))
+	require.NoError(t, err)
+	assert.Equal(t, tree.Size(), restored.Size())
+
+	gotRoot, err := restored.Root()
+	require.NoError(t, err)
+	assert.Equal(t, root, gotRoot)
+
+	for _, d := range data {
+		nID := namespace.ID(d[:nidSize])
+		want, err := tree.ProveNamespace(nID)
+		require.NoError(t, err)
+		got, err := restored.ProveNamespace(nID)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.True(t, got.VerifyNamespace(sha256.New(), nID, tree.Get(nID), root))
+		assert.Nil(t, restored.Get(nID))
+	}
+	err = restored.Push(data[len(data)-1])
+	assert.True(t, errors.Is(err, ErrLeafDataUnavailable))
+
+	// the store does not hold enough leaves
+	_, err = NewFromNodeStore(sha256.New(), store, len(data)+1, NamespaceIDSize(nidSize))
+	assert.True(t, errors.Is(err, ErrNodeNotFound))
+}
+
+func TestFileNodeStore_Compaction(t *testing.T) {
+	const nidSize = 2
+	path := filepath.Join(t.TempDir(), "nodes")
+	store, err := OpenFileNodeStore(path)
+	require.NoError(t, err)
+
+	data, err := generateRandNamespacedRawData(2000, nidSize, 16)
+	require.NoError(t, err)
+	tree := New(sha256.New(), NamespaceIDSize(nidSize), CustomNodeStore(store))
+	for _, d := range data {
+		require.NoError(t, tree.Push(d))
+		_, err := tree.Root()
+		require.NoError(t, err)
+	}
+	root, err := tree.Root()
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// the store holds the leaves and the inner nodes, whose records take a
+	// bounded share of the file despite the nodes replaced after every push
+	live := int64(2*len(data)-1) * (fileRecordHeaderSize + 2*nidSize + sha256.Size)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), 2*live+fileCompactionMinSize)
+
+	store, err = OpenFileNodeStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	restored, err := NewFromNodeStore(sha256.New(), store, len(data), NamespaceIDSize(nidSize))
+	require.NoError(t, err)
+	gotRoot, err := restored.Root()
+	require.NoError(t, err)
+	assert.Equal(t, root, gotRoot)
+}
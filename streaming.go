@@ -0,0 +1,120 @@
+package nmt
+
+import (
+	"fmt"
+	"hash"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// StreamingBuilder computes the root of a namespaced Merkle tree over leaves
+// pushed in namespace order, without retaining the leaves or their hashes. It
+// only keeps a stack of the roots of the complete subtrees that are still
+// waiting for a sibling, i.e., O(log n) hashes for n leaves. The resulting
+// root is identical to the one of a NamespacedMerkleTree with the same leaves
+// and options.
+type StreamingBuilder struct {
+	treeHasher Hasher
+	// stack holds the roots of the pending subtrees, ordered from left to
+	// right. Their sizes are distinct powers of two in decreasing order, so
+	// that they follow the binary representation of the number of leaves.
+	stack []streamingSubtree
+	// size is the number of leaves pushed so far
+	size int
+	// lastNID is the namespace ID of the last pushed leaf
+	lastNID namespace.ID
+}
+
+// streamingSubtree is a complete subtree pending in a StreamingBuilder.
+type streamingSubtree struct {
+	hash []byte
+	// size is the number of leaves of the subtree
+	size int
+}
+
+// NewStreamingBuilder initializes a StreamingBuilder using the given base hash
+// function. It accepts the same options as New, of which only NamespaceIDSize,
+// IgnoreMaxNamespace and CustomHasher apply.
+func NewStreamingBuilder(h hash.Hash, setters ...Option) *StreamingBuilder {
+	// default options:
+	opts := &Options{
+		NamespaceIDSize:    DefaultNamespaceIDLen,
+		IgnoreMaxNamespace: true,
+	}
+	for _, setter := range setters {
+		setter(opts)
+	}
+	opts.Hasher = NewNmtHasher(h, opts.NamespaceIDSize, opts.IgnoreMaxNamespace)
+	// set the options a second time to replace the hasher if needed
+	for _, setter := range setters {
+		setter(opts)
+	}
+	return &StreamingBuilder{treeHasher: opts.Hasher}
+}
+
+// NamespaceSize returns the underlying namespace size.
+func (b *StreamingBuilder) NamespaceSize() namespace.IDSize {
+	return b.treeHasher.NamespaceSize()
+}
+
+// Size returns the number of leaves pushed so far.
+func (b *StreamingBuilder) Size() int {
+	return b.size
+}
+
+// Push adds a namespaced data item as the next leaf of the tree. The data is
+// hashed right away and is not retained. Push returns an error if the data is
+// not namespace-prefixed, or if its namespace ID is smaller than the one of
+// the previously pushed data, just like NamespacedMerkleTree.Push.
+func (b *StreamingBuilder) Push(namespacedData namespace.PrefixedData) error {
+	nidSize := int(b.NamespaceSize())
+	if len(namespacedData) < nidSize {
+		return fmt.Errorf("%w: got: %v, want >= %v", ErrInvalidLeafLen, len(namespacedData), nidSize)
+	}
+	nID := namespace.ID(namespacedData[:nidSize])
+	if b.size > 0 && nID.Less(b.lastNID) {
+		return fmt.Errorf("%w: last namespace: %x, pushed: %x", ErrInvalidPushOrder, b.lastNID, nID)
+	}
+
+	leafHash, err := b.treeHasher.HashLeaf(namespacedData)
+	if err != nil {
+		return err
+	}
+	subtree := streamingSubtree{hash: leafHash, size: 1}
+	// merge the complete subtrees of equal size, like carrying in a binary
+	// addition
+	for len(b.stack) > 0 && b.stack[len(b.stack)-1].size == subtree.size {
+		left := b.stack[len(b.stack)-1]
+		b.stack = b.stack[:len(b.stack)-1]
+		hash, err := b.treeHasher.HashNode(left.hash, subtree.hash)
+		if err != nil {
+			return err
+		}
+		subtree = streamingSubtree{hash: hash, size: left.size * 2}
+	}
+	b.stack = append(b.stack, subtree)
+
+	// keep a copy of the namespace ID since the data is not retained
+	b.lastNID = append(b.lastNID[:0], nID...)
+	b.size++
+	return nil
+}
+
+// Root returns the root of the tree over the leaves pushed so far. The pending
+// subtrees are folded from right to left, which yields the same tree shape as
+// NamespacedMerkleTree.Root. Root does not modify the builder, so more leaves
+// can be pushed afterwards.
+func (b *StreamingBuilder) Root() ([]byte, error) {
+	if b.size == 0 {
+		return b.treeHasher.EmptyRoot(), nil
+	}
+	root := b.stack[len(b.stack)-1].hash
+	for i := len(b.stack) - 2; i >= 0; i-- {
+		var err error
+		root, err = b.treeHasher.HashNode(b.stack[i].hash, root)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
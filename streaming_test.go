@@ -0,0 +1,84 @@
+package nmt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+func TestStreamingBuilder(t *testing.T) {
+	for _, ignoreMaxNamespace := range []bool{true, false} {
+		t.Run(fmt.Sprintf("ignoreMaxNamespace=%v", ignoreMaxNamespace), func(t *testing.T) {
+			const nidSize = 1
+			builder := NewStreamingBuilder(sha256.New(), NamespaceIDSize(nidSize), IgnoreMaxNamespace(ignoreMaxNamespace))
+			tree := New(sha256.New(), NamespaceIDSize(nidSize), IgnoreMaxNamespace(ignoreMaxNamespace))
+
+			for i := 0; i <= 300; i++ {
+				want, err := tree.Root()
+				require.NoError(t, err)
+				got, err := builder.Root()
+				require.NoError(t, err)
+				require.Equal(t, want, got, "size %d", i)
+				assert.Equal(t, tree.Size(), builder.Size())
+				// only one pending subtree per bit of the size is kept
+				assert.Equal(t, bits.OnesCount(uint(i)), len(builder.stack))
+
+				// the last leaves have the maximum namespace
+				d := namespace.PrefixedData(append([]byte{byte(min(i/2, 255))}, []byte(fmt.Sprintf("leaf_%d", i))...))
+				require.NoError(t, tree.Push(d))
+				require.NoError(t, builder.Push(d))
+			}
+		})
+	}
+}
+
+func TestStreamingBuilder_PushErrors(t *testing.T) {
+	builder := NewStreamingBuilder(sha256.New(), NamespaceIDSize(2))
+	require.NoError(t, builder.Push(namespace.PrefixedData{0, 2, 'a'}))
+
+	err := builder.Push(namespace.PrefixedData{0})
+	assert.True(t, errors.Is(err, ErrInvalidLeafLen))
+
+	err = builder.Push(namespace.PrefixedData{0, 1, 'b'})
+	assert.True(t, errors.Is(err, ErrInvalidPushOrder))
+
+	// the failed pushes have no effect
+	assert.Equal(t, 1, builder.Size())
+	require.NoError(t, builder.Push(namespace.PrefixedData{0, 2, 'c'}))
+}
+
+func BenchmarkStreamingBuilder(b *testing.B) {
+	const nidSize = 29
+	data, err := generateRandNamespacedRawData(20000, nidSize, 512)
+	require.NoError(b, err)
+
+	b.Run("20k-leaves-StreamingBuilder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			builder := NewStreamingBuilder(sha256.New(), NamespaceIDSize(nidSize))
+			for _, d := range data {
+				require.NoError(b, builder.Push(d))
+			}
+			_, err := builder.Root()
+			require.NoError(b, err)
+		}
+	})
+	b.Run("20k-leaves-NamespacedMerkleTree", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tree := New(sha256.New(), NamespaceIDSize(nidSize))
+			for _, d := range data {
+				require.NoError(b, tree.Push(d))
+			}
+			_, err := tree.Root()
+			require.NoError(b, err)
+		}
+	})
+}
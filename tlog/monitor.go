@@ -0,0 +1,92 @@
+package tlog
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"hash"
+
+	"github.com/celestiaorg/nmt"
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// Monitor checks the successive tree heads of a log on the client side: each
+// head must be signed by the log, must not be older nor smaller than the
+// previous one, and must be proven to extend it. The first head is checked
+// against the empty tree, so a monitor that accepted a head has verified the
+// whole history of the log up to it.
+type Monitor struct {
+	pub                ed25519.PublicKey
+	h                  hash.Hash
+	nIDSize            namespace.IDSize
+	ignoreMaxNamespace bool
+	head               SignedTreeHead
+	started            bool
+}
+
+// NewMonitor returns a monitor of the log of public key pub, whose tree uses
+// the hash function h, namespaces of nIDSize bytes, and ignores the maximum
+// namespace if ignoreMaxNamespace is true, see nmt.IgnoreMaxNamespace.
+func NewMonitor(pub ed25519.PublicKey, h hash.Hash, nIDSize namespace.IDSize, ignoreMaxNamespace bool) *Monitor {
+	return &Monitor{pub: pub, h: h, nIDSize: nIDSize, ignoreMaxNamespace: ignoreMaxNamespace}
+}
+
+// Head returns the last head accepted by the monitor, and false if it has not
+// accepted any head yet.
+func (m *Monitor) Head() (SignedTreeHead, bool) {
+	return m.head, m.started
+}
+
+// Update checks the head sth, which must be proven to extend the last head
+// accepted by the monitor by proof, i.e., a consistency proof from the size
+// of the last head (0 if there is none) to the size of sth, see
+// Log.ProveConsistency. If the checks pass, sth becomes the last head of the
+// monitor. Otherwise, Update returns an ErrInvalidSignature error for a head
+// that is not signed by the log, and an ErrInconsistentHead error for a head
+// that does not extend the last one; in the latter case, the log has
+// rewritten its history or has forked, and both heads are evidence of it.
+// A proof whose handling of the maximum namespace is not that of the monitor
+// is rejected with an ErrInconsistentHead error as well.
+func (m *Monitor) Update(sth SignedTreeHead, proof nmt.ConsistencyProof) error {
+	if err := sth.Verify(m.pub); err != nil {
+		return err
+	}
+	if proof.IsMaxNamespaceIDIgnored() != m.ignoreMaxNamespace {
+		return fmt.Errorf("%w: proof with the maximum namespace ignored set to %t, expected %t", ErrInconsistentHead, proof.IsMaxNamespaceIDIgnored(), m.ignoreMaxNamespace)
+	}
+	last := m.head
+	if !m.started {
+		last.Root = nmt.NewNmtHasher(m.h, m.nIDSize, m.ignoreMaxNamespace).EmptyRoot()
+	}
+	if sth.Size < last.Size {
+		return fmt.Errorf("%w: size %d is smaller than the size %d of the last head", ErrInconsistentHead, sth.Size, last.Size)
+	}
+	if sth.Timestamp.Before(last.Timestamp) {
+		return fmt.Errorf("%w: timestamp %v is before the timestamp %v of the last head", ErrInconsistentHead, sth.Timestamp, last.Timestamp)
+	}
+	if proof.OldSize() != last.Size || proof.NewSize() != sth.Size {
+		return fmt.Errorf("%w: proof from size %d to size %d, expected from size %d to size %d", ErrInconsistentHead, proof.OldSize(), proof.NewSize(), last.Size, sth.Size)
+	}
+	if err := proof.VerifyConsistencyErr(m.h, m.nIDSize, last.Root, sth.Root); err != nil {
+		return fmt.Errorf("%w: %w", ErrInconsistentHead, err)
+	}
+	m.head = sth
+	m.started = true
+	return nil
+}
+
+// VerifyInclusion checks that proof, e.g., the result of Log.ProveInclusion,
+// proves the inclusion of the namespaced leaf at index in the tree of the
+// head th, whose tree uses the hash function h and namespaces of nIDSize
+// bytes. It returns nil if the proof is valid.
+func VerifyInclusion(h hash.Hash, nIDSize namespace.IDSize, leaf namespace.PrefixedData, index int, proof nmt.Proof, th TreeHead) error {
+	if len(leaf) < int(nIDSize) {
+		return fmt.Errorf("%w: leaf of %d bytes", nmt.ErrInvalidLeafLen, len(leaf))
+	}
+	if proof.Start() != index || proof.End() != index+1 {
+		return fmt.Errorf("%w: proof of [%d, %d) for the leaf at index %d", nmt.ErrInvalidRange, proof.Start(), proof.End(), index)
+	}
+	if proof.TreeSize() != th.Size {
+		return fmt.Errorf("%w: proof for a tree of %d leaves, the head has %d leaves", nmt.ErrInvalidRange, proof.TreeSize(), th.Size)
+	}
+	return proof.VerifyInclusionErr(h, namespace.ID(leaf[:nIDSize]), [][]byte{leaf[nIDSize:]}, th.Root)
+}
@@ -0,0 +1,154 @@
+package tlog
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt"
+)
+
+func TestMonitor(t *testing.T) {
+	log, pub := testLog(t)
+	monitor := NewMonitor(pub, sha256.New(), 1, true)
+	_, ok := monitor.Head()
+	assert.False(t, ok)
+
+	lastSize := 0
+	for _, size := range []int{0, 5, 5, 8, 17} {
+		for i := log.Size(); i < size; i++ {
+			require.NoError(t, log.Append(testLeaf(i)))
+		}
+		sth, err := log.SignHead()
+		require.NoError(t, err)
+		proof, err := log.ProveConsistency(lastSize, sth.Size)
+		require.NoError(t, err)
+		require.NoError(t, monitor.Update(sth, proof))
+		head, ok := monitor.Head()
+		assert.True(t, ok)
+		assert.Equal(t, sth, head)
+		lastSize = size
+	}
+}
+
+func TestMonitor_Update_Errors(t *testing.T) {
+	log, pub := testLog(t)
+	var heads []SignedTreeHead
+	for _, size := range []int{6, 10, 12} {
+		for i := log.Size(); i < size; i++ {
+			require.NoError(t, log.Append(testLeaf(i)))
+		}
+		sth, err := log.SignHead()
+		require.NoError(t, err)
+		heads = append(heads, sth)
+	}
+	head6, head10, head12 := heads[0], heads[1], heads[2]
+	proof0to6, err := log.ProveConsistency(0, 6)
+	require.NoError(t, err)
+	proof6to10, err := log.ProveConsistency(6, 10)
+	require.NoError(t, err)
+
+	// a fork of the log signed with the same key: its history from the third
+	// leaf on differs
+	fork := New(nmt.New(sha256.New(), nmt.NamespaceIDSize(1)), log.key, WithClock(func() time.Time { return head12.Timestamp }))
+	for i := 0; i < 12; i++ {
+		leaf := testLeaf(i)
+		if i >= 2 {
+			leaf = append(leaf, "_forked"...)
+		}
+		require.NoError(t, fork.Append(leaf))
+	}
+	forkedHead12, err := fork.SignHead()
+	require.NoError(t, err)
+	forkedProof10to12, err := fork.ProveConsistency(10, 12)
+	require.NoError(t, err)
+	proof10to12, err := log.ProveConsistency(10, 12)
+	require.NoError(t, err)
+	// the valid proof, but claiming that the maximum namespace is not ignored
+	otherNamespaceProof10to12 := nmt.NewConsistencyProof(10, 12, proof10to12.Nodes(), false)
+
+	// a head signed by another key
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	otherHead, err := New(nmt.New(sha256.New(), nmt.NamespaceIDSize(1)), key).SignHead()
+	require.NoError(t, err)
+
+	// a head that is older than the last one
+	stale := head10
+	stale.Timestamp = head6.Timestamp
+	stale.Signature = ed25519.Sign(log.key, stale.SignedMessage())
+
+	tests := []struct {
+		name    string
+		sth     SignedTreeHead
+		proof   nmt.ConsistencyProof
+		wantErr error
+	}{
+		{"other key", otherHead, nmt.NewConsistencyProof(10, 0, nil, true), ErrInvalidSignature},
+		{"smaller size", head6, nmt.NewConsistencyProof(6, 6, nil, true), ErrInconsistentHead},
+		{"older timestamp", stale, nmt.NewConsistencyProof(10, 10, nil, true), ErrInconsistentHead},
+		{"proof from another size", head12, proof0to6, ErrInconsistentHead},
+		{"forked history", forkedHead12, forkedProof10to12, ErrInconsistentHead},
+		{"proof of a forked history", head12, forkedProof10to12, ErrInconsistentHead},
+		{"proof not ignoring the maximum namespace", head12, otherNamespaceProof10to12, ErrInconsistentHead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := NewMonitor(pub, sha256.New(), 1, true)
+			require.NoError(t, monitor.Update(head6, proof0to6))
+			require.NoError(t, monitor.Update(head10, proof6to10))
+			err := monitor.Update(tt.sth, tt.proof)
+			assert.ErrorIs(t, err, tt.wantErr)
+			// the monitor keeps the last head it accepted
+			head, ok := monitor.Head()
+			assert.True(t, ok)
+			assert.Equal(t, head10, head)
+		})
+	}
+}
+
+func TestMonitor_Update_EmptyHead(t *testing.T) {
+	log, pub := testLog(t)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, log.Append(testLeaf(i)))
+	}
+	head4, err := log.SignHead()
+	require.NoError(t, err)
+
+	// a head of size 0 whose root is not the root of the empty tree
+	forged := head4
+	forged.Size = 0
+	forged.Signature = ed25519.Sign(log.key, forged.SignedMessage())
+	monitor := NewMonitor(pub, sha256.New(), 1, true)
+	err = monitor.Update(forged, nmt.NewConsistencyProof(0, 0, nil, true))
+	assert.ErrorIs(t, err, ErrInconsistentHead)
+	assert.ErrorIs(t, err, nmt.ErrRootMismatch)
+	_, ok := monitor.Head()
+	assert.False(t, ok)
+}
+
+func TestMonitor_Update_IgnoreMaxNamespace(t *testing.T) {
+	log, pub := testLog(t)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, log.Append(testLeaf(i)))
+	}
+	head4, err := log.SignHead()
+	require.NoError(t, err)
+	proof, err := log.ProveConsistency(0, 4)
+	require.NoError(t, err)
+
+	// the log ignores the maximum namespace, a monitor expecting otherwise
+	// rejects its proofs
+	monitor := NewMonitor(pub, sha256.New(), 1, false)
+	err = monitor.Update(head4, proof)
+	assert.ErrorIs(t, err, ErrInconsistentHead)
+	_, ok := monitor.Head()
+	assert.False(t, ok)
+
+	monitor = NewMonitor(pub, sha256.New(), 1, true)
+	require.NoError(t, monitor.Update(head4, proof))
+}
@@ -0,0 +1,182 @@
+// Package tlog implements an append-only transparency log over a namespaced
+// Merkle tree. The log signs tree heads, i.e., the size and root of the tree
+// at a point in time, with ed25519, and serves the inclusion proofs of its
+// leaves and the consistency proofs between its heads. A Monitor checks that
+// the successive heads of a log are signed by it and extend each other.
+package tlog
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/nmt"
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature of a tree head does
+	// not verify under the public key of the log.
+	ErrInvalidSignature = errors.New("invalid tree head signature")
+	// ErrInconsistentHead is returned when a tree head does not extend the
+	// previous head of the log.
+	ErrInconsistentHead = errors.New("inconsistent tree head")
+)
+
+// treeHeadPrefix is prepended to the encoding of a tree head before it is
+// signed, so that its signature can not be mistaken for that of other data
+// signed with the same key.
+const treeHeadPrefix = "nmt tree head v1\n"
+
+// TreeHead is the state of a log at a point in time.
+type TreeHead struct {
+	// Size is the number of leaves of the tree.
+	Size int
+	// Root is the root of the tree of the first Size leaves of the log.
+	Root []byte
+	// Timestamp is the time at which the head was issued.
+	Timestamp time.Time
+}
+
+// SignedMessage returns the message signed for the tree head: a constant
+// prefix, followed by the size and the timestamp in nanoseconds since the Unix
+// epoch as big-endian 64-bit integers, and the root.
+func (th TreeHead) SignedMessage() []byte {
+	msg := make([]byte, 0, len(treeHeadPrefix)+16+len(th.Root))
+	msg = append(msg, treeHeadPrefix...)
+	msg = binary.BigEndian.AppendUint64(msg, uint64(th.Size))
+	msg = binary.BigEndian.AppendUint64(msg, uint64(th.Timestamp.UnixNano()))
+	return append(msg, th.Root...)
+}
+
+// SignedTreeHead is a tree head signed by the log.
+type SignedTreeHead struct {
+	TreeHead
+	// Signature is the ed25519 signature of TreeHead.SignedMessage.
+	Signature []byte
+}
+
+// Verify returns an ErrInvalidSignature error if the signature of the tree
+// head does not verify under the public key pub, and nil otherwise.
+func (sth SignedTreeHead) Verify(pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: public key of %d bytes", ErrInvalidSignature, len(pub))
+	}
+	if !ed25519.Verify(pub, sth.SignedMessage(), sth.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Option is the functional option that is applied to the Log instance to
+// configure it.
+type Option func(*Log)
+
+// WithClock sets the function returning the timestamps of the tree heads
+// signed by the log. The default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(l *Log) {
+		l.now = now
+	}
+}
+
+// Log is an append-only log of namespaced leaves. It is safe for concurrent
+// use.
+type Log struct {
+	mu    sync.RWMutex
+	tree  *nmt.NamespacedMerkleTree
+	key   ed25519.PrivateKey
+	now   func() time.Time
+	heads []SignedTreeHead
+}
+
+// New returns a log of the leaves of tree, whose tree heads are signed with
+// key. The leaves must only be pushed to the tree through the log from now on.
+func New(tree *nmt.NamespacedMerkleTree, key ed25519.PrivateKey, opts ...Option) *Log {
+	l := &Log{
+		tree: tree,
+		key:  key,
+		now:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// PublicKey returns the public key verifying the tree heads of the log.
+func (l *Log) PublicKey() ed25519.PublicKey {
+	return l.key.Public().(ed25519.PublicKey)
+}
+
+// Size returns the number of leaves of the log.
+func (l *Log) Size() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tree.Size()
+}
+
+// Append appends the leaves to the log, see NamespacedMerkleTree.Push. If a
+// leaf can not be pushed, the leaves before it are still appended.
+func (l *Log) Append(leaves ...namespace.PrefixedData) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, leaf := range leaves {
+		if err := l.tree.Push(leaf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SignHead signs a head of the current tree of the log and records it in the
+// heads of the log. Its timestamp is never before the one of the previous
+// head, even if the clock of the log went backwards.
+func (l *Log) SignHead() (SignedTreeHead, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	root, err := l.tree.Root()
+	if err != nil {
+		return SignedTreeHead{}, err
+	}
+	head := TreeHead{Size: l.tree.Size(), Root: root, Timestamp: l.now()}
+	if len(l.heads) > 0 {
+		if last := l.heads[len(l.heads)-1].Timestamp; head.Timestamp.Before(last) {
+			head.Timestamp = last
+		}
+	}
+	sth := SignedTreeHead{
+		TreeHead:  head,
+		Signature: ed25519.Sign(l.key, head.SignedMessage()),
+	}
+	l.heads = append(l.heads, sth)
+	return sth, nil
+}
+
+// Heads returns the heads signed by the log, in the order they were signed.
+func (l *Log) Heads() []SignedTreeHead {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]SignedTreeHead(nil), l.heads...)
+}
+
+// ProveInclusion returns the proof of the inclusion of the leaf at index in
+// the tree of the first treeSize leaves of the log, e.g., the size of a head
+// signed by the log, see NamespacedMerkleTree.ProveRangeAt.
+func (l *Log) ProveInclusion(index, treeSize int) (nmt.Proof, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tree.ProveRangeAt(index, index+1, treeSize)
+}
+
+// ProveConsistency returns the proof that the tree of the first oldSize leaves
+// of the log is a prefix of the tree of its first newSize leaves, see
+// NamespacedMerkleTree.ProveConsistencyBetween.
+func (l *Log) ProveConsistency(oldSize, newSize int) (nmt.ConsistencyProof, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tree.ProveConsistencyBetween(oldSize, newSize)
+}
@@ -0,0 +1,123 @@
+package tlog
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt"
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// testLog returns a log of a tree with namespaces of 1 byte, whose clock
+// advances by a second on every call.
+func testLog(t *testing.T) (*Log, ed25519.PublicKey) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return New(nmt.New(sha256.New(), nmt.NamespaceIDSize(1)), key, WithClock(clock)), pub
+}
+
+func testLeaf(i int) namespace.PrefixedData {
+	return append([]byte{byte(i / 3)}, fmt.Sprintf("leaf_%d", i)...)
+}
+
+func TestLog(t *testing.T) {
+	log, pub := testLog(t)
+	assert.Equal(t, pub, log.PublicKey())
+
+	var heads []SignedTreeHead
+	for size := 0; size <= 12; size += 3 {
+		for i := log.Size(); i < size; i++ {
+			require.NoError(t, log.Append(testLeaf(i)))
+		}
+		sth, err := log.SignHead()
+		require.NoError(t, err)
+		assert.Equal(t, size, sth.Size)
+		require.NoError(t, sth.Verify(pub))
+		heads = append(heads, sth)
+	}
+	assert.Equal(t, heads, log.Heads())
+
+	// every leaf is included in every head of the log that contains it
+	for _, sth := range heads {
+		for i := 0; i < sth.Size; i++ {
+			proof, err := log.ProveInclusion(i, sth.Size)
+			require.NoError(t, err)
+			require.NoError(t, VerifyInclusion(sha256.New(), 1, testLeaf(i), i, proof, sth.TreeHead))
+			assert.Error(t, VerifyInclusion(sha256.New(), 1, testLeaf(i+1), i, proof, sth.TreeHead))
+		}
+	}
+
+	// every head is consistent with the later ones
+	for i, oldHead := range heads {
+		for _, newHead := range heads[i:] {
+			proof, err := log.ProveConsistency(oldHead.Size, newHead.Size)
+			require.NoError(t, err)
+			require.NoError(t, proof.VerifyConsistencyErr(sha256.New(), 1, oldHead.Root, newHead.Root))
+		}
+	}
+
+	_, err := log.ProveInclusion(12, 12)
+	assert.ErrorIs(t, err, nmt.ErrInvalidRange)
+	_, err = log.ProveConsistency(6, 13)
+	assert.ErrorIs(t, err, nmt.ErrInvalidRange)
+	assert.ErrorIs(t, log.Append(testLeaf(0)), nmt.ErrInvalidPushOrder)
+	assert.Equal(t, 12, log.Size())
+}
+
+func TestLog_SignHead_Timestamp(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	log := New(nmt.New(sha256.New()), key, WithClock(func() time.Time { return now }))
+
+	first, err := log.SignHead()
+	require.NoError(t, err)
+	// the clock of the log goes backwards
+	now = now.Add(-time.Hour)
+	second, err := log.SignHead()
+	require.NoError(t, err)
+	assert.Equal(t, first.Timestamp, second.Timestamp)
+	require.NoError(t, second.Verify(pub))
+}
+
+func TestSignedTreeHead_Verify(t *testing.T) {
+	log, pub := testLog(t)
+	require.NoError(t, log.Append(testLeaf(0)))
+	sth, err := log.SignHead()
+	require.NoError(t, err)
+	require.NoError(t, sth.Verify(pub))
+
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		modify func(sth *SignedTreeHead)
+		pub    ed25519.PublicKey
+	}{
+		{"other key", func(*SignedTreeHead) {}, otherPub},
+		{"malformed key", func(*SignedTreeHead) {}, pub[:1]},
+		{"size", func(sth *SignedTreeHead) { sth.Size++ }, pub},
+		{"root", func(sth *SignedTreeHead) { sth.Root = append([]byte{1}, sth.Root[1:]...) }, pub},
+		{"timestamp", func(sth *SignedTreeHead) { sth.Timestamp = sth.Timestamp.Add(time.Nanosecond) }, pub},
+		{"signature", func(sth *SignedTreeHead) { sth.Signature = sth.Signature[1:] }, pub},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := sth
+			tt.modify(&modified)
+			assert.ErrorIs(t, modified.Verify(tt.pub), ErrInvalidSignature)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package nmt
+
+import (
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// TraceStepKind is the kind of a step of a proof verification trace.
+type TraceStepKind int
+
+const (
+	// TraceLeaf is the step of consuming the hash of a proven leaf.
+	TraceLeaf TraceStepKind = iota
+	// TraceProofNode is the step of consuming a node of the proof.
+	TraceProofNode
+	// TraceHashNode is the step of hashing two children into their parent.
+	TraceHashNode
+	// TraceCompletenessCheck is the step of checking that a node of the proof
+	// does not cover any leaf of the proven namespace.
+	TraceCompletenessCheck
+)
+
+func (k TraceStepKind) String() string {
+	switch k {
+	case TraceLeaf:
+		return "leaf"
+	case TraceProofNode:
+		return "proof node"
+	case TraceHashNode:
+		return "hash"
+	case TraceCompletenessCheck:
+		return "completeness"
+	default:
+		return fmt.Sprintf("TraceStepKind(%d)", int(k))
+	}
+}
+
+// TraceStep is a single step of a proof verification trace.
+type TraceStep struct {
+	Kind TraceStepKind
+	// Range is the range of leaves covered by the node of the step. Unless the
+	// proof records the size of the tree, see Proof.TreeSize, ranges are
+	// positions in the smallest tree whose size is a power of two and which
+	// holds the proof range, hence they may extend past the last leaf of the
+	// tree. The End of the nodes beyond that tree is not known to the verifier
+	// and is set to -1.
+	Range LeafRange
+	// Index is the index of the consumed leaf hash or proof node, or -1 for
+	// the TraceHashNode steps.
+	Index int
+	// Left and Right are the children hashed by a TraceHashNode step.
+	Left, Right []byte
+	// Hash is the namespaced hash of the node of the step.
+	Hash []byte
+	// Passed reports whether a TraceCompletenessCheck step passed.
+	Passed bool
+}
+
+// ProofTrace is the trace of the verification of a namespace proof, see
+// Proof.Explain.
+type ProofTrace struct {
+	Namespace  namespace.ID
+	Start, End int
+	Steps      []TraceStep
+	// Root is the root computed from the proof, or nil if it could not be
+	// computed.
+	Root         []byte
+	ExpectedRoot []byte
+	// Err is the result of Proof.VerifyNamespaceErr, i.e., nil if and only if
+	// the proof is valid.
+	Err error
+}
+
+// Explain verifies the proof like VerifyNamespaceErr and returns a trace of the
+// verification: every leaf hash and proof node consumed and every node hashed
+// while computing the root, in that order, followed by the completeness check
+// of every proof node. The trace is meant for debugging failing proofs and for
+// comparing with other verifier implementations; the hashing steps are traced
+// as far as they go even if the proof fails an earlier validation.
+func (proof Proof) Explain(h hash.Hash, nID namespace.ID, leaves [][]byte, root []byte) *ProofTrace {
+	trace := &ProofTrace{
+		Namespace:    nID,
+		Start:        proof.start,
+		End:          proof.end,
+		ExpectedRoot: root,
+		Err:          proof.VerifyNamespaceErr(h, nID, leaves, root),
+	}
+	if proof.start < 0 || proof.start >= proof.end {
+		return trace
+	}
+
+	nth := NewNmtHasher(h, nID.Size(), proof.isMaxNamespaceIDIgnored)
+	leafHashes := make([][]byte, 0, len(leaves))
+	if proof.IsOfAbsence() {
+		leafHashes = append(leafHashes, proof.leafHash)
+	} else {
+		for _, leaf := range leaves {
+			leafHash, err := nth.HashLeaf(leaf)
+			if err != nil {
+				return trace
+			}
+			leafHashes = append(leafHashes, leafHash)
+		}
+	}
+
+	nodeRanges := make([]LeafRange, 0, len(proof.nodes))
+	rootHash, _, err := computeRangesRoot(nth, []LeafRange{{Start: proof.start, End: proof.end}}, leafHashes, proof.nodes, proof.treeSize, func(step TraceStep) {
+		if step.Kind == TraceProofNode {
+			nodeRanges = append(nodeRanges, step.Range)
+		}
+		trace.Steps = append(trace.Steps, step)
+	})
+	if err == nil {
+		trace.Root = rootHash
+	}
+
+	for i, r := range nodeRanges {
+		node := proof.nodes[i]
+		if nth.ValidateNodeFormat(node) != nil {
+			continue
+		}
+		trace.Steps = append(trace.Steps, TraceStep{
+			Kind:   TraceCompletenessCheck,
+			Range:  r,
+			Index:  i,
+			Hash:   node,
+			Passed: isOutsideNamespace(nth, nID, node, r.Start < proof.start),
+		})
+	}
+	return trace
+}
+
+// String renders the trace as text, one step per line.
+func (t *ProofTrace) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "namespace %x, proof range [%d, %d)\n", []byte(t.Namespace), t.Start, t.End)
+	for _, step := range t.Steps {
+		fmt.Fprintf(&b, "%-12s %-10s ", step.Kind, formatTraceRange(step.Range))
+		switch step.Kind {
+		case TraceLeaf:
+			fmt.Fprintf(&b, "leaves[%d] = %x\n", step.Index, step.Hash)
+		case TraceProofNode:
+			fmt.Fprintf(&b, "nodes[%d] = %x\n", step.Index, step.Hash)
+		case TraceHashNode:
+			fmt.Fprintf(&b, "H(%x, %x) = %x\n", step.Left, step.Right, step.Hash)
+		case TraceCompletenessCheck:
+			result := "ok"
+			if !step.Passed {
+				result = "FAILED"
+			}
+			fmt.Fprintf(&b, "nodes[%d] namespace range [%x, %x]: %s\n", step.Index, MinNamespace(step.Hash, t.Namespace.Size()), MaxNamespace(step.Hash, t.Namespace.Size()), result)
+		}
+	}
+	fmt.Fprintf(&b, "computed root %x\n", t.Root)
+	fmt.Fprintf(&b, "expected root %x\n", t.ExpectedRoot)
+	if t.Err != nil {
+		fmt.Fprintf(&b, "result: %v\n", t.Err)
+	} else {
+		b.WriteString("result: ok\n")
+	}
+	return b.String()
+}
+
+func formatTraceRange(r LeafRange) string {
+	if r.End < 0 {
+		return fmt.Sprintf("[%d, ?)", r.Start)
+	}
+	return fmt.Sprintf("[%d, %d)", r.Start, r.End)
+}
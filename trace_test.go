@@ -0,0 +1,126 @@
+package nmt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+func TestProof_Explain(t *testing.T) {
+	tree := exampleNMT(1, true, 1, 2, 3, 3, 4, 5, 6, 7, 8)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	nth := NewNmtHasher(sha256.New(), 1, true)
+
+	nID := namespace.ID{3}
+	proof, err := tree.ProveNamespace(nID)
+	require.NoError(t, err)
+	leaves := tree.Get(nID)
+	partialProof, err := tree.ProveRange(2, 3)
+	require.NoError(t, err)
+	absentNID := namespace.ID{0}
+	absenceProof, err := exampleNMT(1, true, 1, 2, 4, 5).ProveNamespace(namespace.ID{3})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		proof      Proof
+		nID        namespace.ID
+		leaves     [][]byte
+		root       []byte
+		wantErr    error
+		wantFailed int
+	}{
+		{"valid proof", proof, nID, leaves, root, nil, 0},
+		{"wrong root", proof, nID, leaves, absenceProof.leafHash, ErrRootMismatch, 0},
+		{"incomplete proof", partialProof, nID, leaves[:1], root, ErrFailedCompletenessCheck, 1},
+		{"empty proof", NewEmptyRangeProof(true), absentNID, nil, root, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := tt.proof.Explain(sha256.New(), tt.nID, tt.leaves, tt.root)
+			if tt.wantErr == nil {
+				require.NoError(t, trace.Err)
+			} else {
+				require.ErrorIs(t, trace.Err, tt.wantErr)
+			}
+			assert.Equal(t, tt.proof.Start(), trace.Start)
+			assert.Equal(t, tt.proof.End(), trace.End)
+			assert.Equal(t, tt.root, trace.ExpectedRoot)
+
+			var leafSteps, nodeSteps, failed int
+			var last []byte
+			for _, step := range trace.Steps {
+				switch step.Kind {
+				case TraceLeaf:
+					assert.Equal(t, leafSteps, step.Index)
+					leafSteps++
+				case TraceProofNode:
+					assert.Equal(t, tt.proof.Nodes()[nodeSteps], step.Hash)
+					nodeSteps++
+				case TraceHashNode:
+					hash, err := nth.HashNode(step.Left, step.Right)
+					require.NoError(t, err)
+					assert.Equal(t, hash, step.Hash)
+					last = step.Hash
+				case TraceCompletenessCheck:
+					if !step.Passed {
+						failed++
+					}
+				}
+			}
+			if !tt.proof.IsEmptyProof() {
+				assert.Equal(t, tt.proof.End()-tt.proof.Start(), leafSteps)
+				assert.Equal(t, len(tt.proof.Nodes()), nodeSteps)
+				assert.Equal(t, last, trace.Root)
+			}
+			assert.Equal(t, tt.wantFailed, failed)
+
+			text := trace.String()
+			if tt.wantErr == nil {
+				assert.Contains(t, text, "result: ok")
+			} else {
+				assert.Contains(t, text, tt.wantErr.Error())
+			}
+		})
+	}
+}
+
+func TestProof_Explain_Ranges(t *testing.T) {
+	// 5 leaves: unless the proof records the size of the tree, the proof node
+	// of the last leaf lies beyond the subtree of size 4 holding the proof
+	// range, so its end is unknown to the verifier
+	tree := exampleNMT(1, true, 1, 2, 3, 4, 5)
+	root, err := tree.Root()
+	require.NoError(t, err)
+	proof, err := tree.ProveNamespace(namespace.ID{3})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		proof      Proof
+		wantRanges []LeafRange
+		wantText   string
+	}{
+		{"known tree size", proof, []LeafRange{{Start: 0, End: 2}, {Start: 3, End: 4}, {Start: 4, End: 5}}, "[4, 5)"},
+		{"unknown tree size", proof.WithTreeSize(0), []LeafRange{{Start: 0, End: 2}, {Start: 3, End: 4}, {Start: 4, End: -1}}, "[4, ?)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := tt.proof.Explain(sha256.New(), namespace.ID{3}, tree.Get(namespace.ID{3}), root)
+			require.NoError(t, trace.Err)
+			var ranges []LeafRange
+			for _, step := range trace.Steps {
+				if step.Kind == TraceProofNode {
+					ranges = append(ranges, step.Range)
+				}
+			}
+			assert.Equal(t, tt.wantRanges, ranges)
+			assert.Contains(t, trace.String(), tt.wantText)
+		})
+	}
+}